@@ -12,6 +12,12 @@ type (
 	Client struct {
 		oauth.OAuth2
 	}
+
+	userResponse struct {
+		UserID oauth.ID `json:"user_id"`
+		Name   string   `json:"name"`
+		Email  string   `json:"email"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -24,9 +30,25 @@ var Endpoint = oauth.Endpoint{
 	TokenHeader:     "Bearer",
 }
 
+func init() {
+	oauth.Register("amazon", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
+}
+
 // https://drive.amazonaws.com/drive/v1/account/endpoint
 
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
 	var req *http.Request
 	if req, err = http.NewRequest("GET", "https://api.amazon.com/user/profile", nil); err != nil {
@@ -35,26 +57,26 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
+		return
+	}
+	if res.UserID == "" {
+		err = oauth.UnexpectedResponse(raw, "user_id is missing or invalid")
 		return
 	}
 
 	user = &oauth.User{
-		ID:      raw["user_id"].(string),
-		Raw:     raw,
-		Updated: &now,
-	}
-	if v, ok := raw["name"].(string); ok {
-		user.Username = v
+		ID:       res.UserID.String(),
+		Username: res.Name,
+		Raw:      raw,
+		Updated:  &now,
 	}
-	if v, ok := raw["email"].(string); ok && v != "" {
-		if user.Auths == nil {
-			user.Auths = make([]*oauth.Auth, 0)
-		}
+	if res.Email != "" {
 		user.Auths = append(user.Auths, &oauth.Auth{
 			Type:     "email",
-			Value:    v,
+			Value:    res.Email,
 			Verified: true,
 		})
 	}
@@ -0,0 +1,30 @@
+package amazon
+
+import (
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token"}, []providertest.Case{
+		{Name: "missing user_id", Body: `{"name":"a","email":"a@example.com"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "string user_id", Body: `{"user_id":"amzn1.account.AAAA","name":"a","email":"a@example.com"}`, ID: "amzn1.account.AAAA", Check: func(t *testing.T, user *oauth.User) {
+			if user.Username != "a" || len(user.Auths) != 1 || user.Auths[0].Value != "a@example.com" {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "number user_id", Body: `{"user_id":1234567890123456789}`, ID: "1234567890123456789"},
+		{Name: "float user_id", Body: `{"user_id":1.5}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "object user_id", Body: `{"user_id":{"id":"1"}}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"user_id":"1","name":5,"email":["a@example.com"]}`, ID: "1", Check: func(t *testing.T, user *oauth.User) {
+			if user.Username != "" || len(user.Auths) != 0 {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "error with status 200", Body: `{"error":"invalid_token","error_description":"The request has an invalid parameter"}`, Err: oauth.ErrTokenInvalid},
+	})
+}
@@ -13,6 +13,16 @@ type (
 	Client struct {
 		oauth.OAuth2
 	}
+
+	userResponse struct {
+		UserID     oauth.ID `json:"userid"`
+		Username   string   `json:"username"`
+		Name       string   `json:"name"`
+		UserDetail string   `json:"userdetail"`
+		Birthday   string   `json:"birthday"`
+		Portrait   string   `json:"portrait"`
+		Sex        string   `json:"sex"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -22,6 +32,31 @@ var Endpoint = oauth.Endpoint{
 	RefreshTokenURL: "https://openapi.baidu.com/oauth/2.0/token",
 	RevokeTokenURL:  "https://openapi.baidu.com/rest/2.0/passport/auth/expireSession",
 	APIURL:          "https://openapi.baidu.com/rest/2.0",
+	Errors:          []string{"error_msg", "error_code"},
+	// http://developer.baidu.com/wiki/index.php?title=docs/oauth/error
+	ErrorCodes: map[string]string{
+		"4":   "rate_limited",
+		"17":  "rate_limited",
+		"18":  "rate_limited",
+		"19":  "rate_limited",
+		"110": "token_invalid",
+		"111": "token_expired",
+	},
+}
+
+func init() {
+	oauth.Register("baidu", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
 }
 
 func (c *Client) RevokeToken(ctx context.Context, token *oauth.Token, values url.Values) (err error) {
@@ -36,6 +71,7 @@ func (c *Client) RevokeToken(ctx context.Context, token *oauth.Token, values url
 }
 
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
 	var req *http.Request
 	if req, err = http.NewRequest("GET", c.Endpoint.APIURL+"/passport/users/getInfo", nil); err != nil {
@@ -43,41 +79,38 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 	}
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
+		return
+	}
+	if res.UserID == "" {
+		err = oauth.UnexpectedResponse(raw, "userid is missing or invalid")
 		return
 	}
 
 	user = &oauth.User{
-		ID:      raw["userid"].(string),
-		Raw:     raw,
-		Updated: &now,
+		ID:          res.UserID.String(),
+		Username:    res.Username,
+		Name:        res.Name,
+		Description: res.UserDetail,
+		Raw:         raw,
+		Updated:     &now,
 	}
 
-	if v, ok := raw["username"].(string); ok && v != "" {
-		user.Username = v
-	}
-	if v, ok := raw["name"].(string); ok && v != "" {
-		user.Name = v
-	}
-	if v, ok := raw["userdetail"].(string); ok {
-		user.Description = v
-	}
-	if v, ok := raw["birthday"].(string); ok && v != "" && v != "0000-00-00" {
-		if birthday, err := time.Parse("2006/01/02", v); err == nil {
+	if res.Birthday != "" && res.Birthday != "0000-00-00" {
+		if birthday, err := time.Parse("2006/01/02", res.Birthday); err == nil {
 			user.Birthday = &birthday
 		}
 	}
-	if v, ok := raw["portrait"].(string); ok && v != "" {
-		user.Avatar = "http://tb.himg.baidu.com/sys/portrait/item/" + v
+	if res.Portrait != "" {
+		user.Avatar = "http://tb.himg.baidu.com/sys/portrait/item/" + res.Portrait
 	}
-	if v, ok := raw["sex"].(string); ok {
-		switch v {
-		case "1":
-			user.Gender = "male"
-		case "0":
-			user.Gender = "female"
-		}
+	switch res.Sex {
+	case "1":
+		user.Gender = "male"
+	case "0":
+		user.Gender = "female"
 	}
 
 	return
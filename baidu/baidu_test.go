@@ -0,0 +1,33 @@
+package baidu
+
+import (
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token"}, []providertest.Case{
+		{Name: "missing userid", Body: `{"username":"a","portrait":"e2c1776c31393837"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "string userid", Body: `{"userid":"2097322476","username":"a","portrait":"e2c1776c31393837","birthday":"1987/01/01","sex":"1"}`, ID: "2097322476", Check: func(t *testing.T, user *oauth.User) {
+			if user.Username != "a" || user.Gender != "male" || user.Birthday == nil || user.Avatar == "" {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "number userid", Body: `{"userid":2097322476}`, ID: "2097322476"},
+		{Name: "float userid", Body: `{"userid":2.0973e9}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"userid":"1","username":5,"userdetail":[],"birthday":19870101,"portrait":{},"sex":1}`, ID: "1", Check: func(t *testing.T, user *oauth.User) {
+			if user.Username != "" || user.Gender != "" || user.Birthday != nil || user.Avatar != "" {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "zero birthday", Body: `{"userid":"1","birthday":"0000-00-00"}`, ID: "1"},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "invalid token code with status 200", Body: `{"error_code":110,"error_msg":"Access token invalid or no longer valid"}`, Err: oauth.ErrTokenInvalid},
+		{Name: "expired token code with status 200", Body: `{"error_code":111,"error_msg":"Access token expired"}`, Err: oauth.ErrTokenExpired},
+		{Name: "qps limit code with status 200", Body: `{"error_code":18,"error_msg":"Open api qps request limit reached"}`, Err: oauth.ErrRateLimited},
+		{Name: "error with status 200", Body: `{"error":"invalid_token","error_description":"Access token invalid or no longer valid"}`, Err: oauth.ErrTokenInvalid},
+	})
+}
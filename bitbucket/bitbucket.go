@@ -12,6 +12,28 @@ type (
 	Client struct {
 		oauth.OAuth2
 	}
+
+	userResponse struct {
+		AccountID   oauth.ID `json:"account_id"`
+		Username    string   `json:"username"`
+		DisplayName string   `json:"display_name"`
+		CreatedOn   string   `json:"created_on"`
+		Links       struct {
+			Avatar link `json:"avatar"`
+			HTML   link `json:"html"`
+		} `json:"links"`
+	}
+
+	emailsResponse struct {
+		Values []struct {
+			Email       string `json:"email"`
+			IsConfirmed bool   `json:"is_confirmed"`
+		} `json:"values"`
+	}
+
+	link struct {
+		Href string `json:"href"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -24,7 +46,23 @@ var Endpoint = oauth.Endpoint{
 	TokenHeader:     "Bearer",
 }
 
+func init() {
+	oauth.Register("bitbucket", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
+}
+
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
 	var req *http.Request
 	if req, err = http.NewRequest("GET", c.Endpoint.APIURL+"/user", nil); err != nil {
@@ -32,59 +70,51 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 	}
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
+		return
+	}
+	if res.AccountID == "" {
+		err = oauth.UnexpectedResponse(raw, "account_id is missing or invalid")
 		return
 	}
 
 	user = &oauth.User{
-		ID:      raw["account_id"].(string),
-		Raw:     raw,
-		Updated: &now,
+		ID:       res.AccountID.String(),
+		Username: res.Username,
+		Nickname: res.DisplayName,
+		Avatar:   res.Links.Avatar.Href,
+		Raw:      raw,
+		Updated:  &now,
 	}
 
-	if v, ok := raw["username"].(string); ok && v != "" {
-		user.Username = v
-		user.Link = "https://bitbucket.com/" + v + "/"
+	if res.Username != "" {
+		user.Link = "https://bitbucket.com/" + res.Username + "/"
 	}
-	if v, ok := raw["display_name"].(string); ok && v != "" {
-		user.Nickname = v
+	if res.Links.HTML.Href != "" {
+		user.Link = res.Links.HTML.Href
 	}
-	if v, ok := raw["created_on"].(string); ok && v != "" {
-		if created, err := time.Parse(time.RFC3339, v); err == nil {
+	if res.CreatedOn != "" {
+		if created, err := time.Parse(time.RFC3339, res.CreatedOn); err == nil {
 			user.Created = &created
 		}
 	}
-	if links, ok := raw["links"].(map[string]interface{}); ok && links != nil {
-		if vv, ok := links["avatar"].(map[string]interface{}); ok && vv != nil {
-			if vvv, ok := vv["href"].(string); ok && vvv != "" {
-				user.Avatar = vvv
-			}
-		}
-		if vv, ok := links["html"].(map[string]interface{}); ok && vv != nil {
-			if vvv, ok := vv["href"].(string); ok && vvv != "" {
-				user.Link = vvv
-			}
-		}
-	}
 
 	{
 		if req, err := http.NewRequest("GET", c.Endpoint.APIURL+"/user/emails", nil); err == nil {
-			if raw, err := c.Response(ctx, httpClient, req); err == nil {
+			var emails emailsResponse
+			if raw, err := c.ResponseInto(ctx, httpClient, req, &emails); err == nil {
 				user.Raw["email"] = raw
-				if values, ok := raw["values"].([]interface{}); ok && values != nil {
-					for _, value := range values {
-						if val, ok := value.(map[string]interface{}); ok && val != nil {
-							if user.Auths == nil {
-								user.Auths = make([]*oauth.Auth, 0)
-							}
-							user.Auths = append(user.Auths, &oauth.Auth{
-								Type:     "email",
-								Value:    val["email"].(string),
-								Verified: val["is_confirmed"].(bool),
-							})
-						}
+				for _, value := range emails.Values {
+					if value.Email == "" {
+						continue
 					}
+					user.Auths = append(user.Auths, &oauth.Auth{
+						Type:     "email",
+						Value:    value.Email,
+						Verified: value.IsConfirmed,
+					})
 				}
 			}
 		}
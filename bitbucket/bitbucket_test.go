@@ -0,0 +1,54 @@
+package bitbucket
+
+import (
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	const user = `{"account_id":"557058:c0b72ad0","username":"evzijst","links":{"html":{"href":"https://bitbucket.org/evzijst/"}}}`
+	emails := func(paths ...string) func(t *testing.T, user *oauth.User) {
+		return func(t *testing.T, user *oauth.User) {
+			var got []string
+			for _, auth := range user.Auths {
+				verified := ""
+				if auth.Verified {
+					verified = " verified"
+				}
+				got = append(got, auth.Value+verified)
+			}
+			if len(got) != len(paths) {
+				t.Fatalf("User().Auths = %v, want %v", got, paths)
+			}
+			for i := range got {
+				if got[i] != paths[i] {
+					t.Fatalf("User().Auths = %v, want %v", got, paths)
+				}
+			}
+		}
+	}
+	body := func(emails string) map[string]string {
+		return map[string]string{"/2.0/user": user, "/2.0/user/emails": emails}
+	}
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token"}, []providertest.Case{
+		{Name: "missing account_id", Body: `{"username":"evzijst"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "string account_id", Paths: body(`{"values":[{"email":"a@example.com","is_confirmed":true},{"email":"b@example.com","is_confirmed":false}]}`), ID: "557058:c0b72ad0", Check: emails("a@example.com verified", "b@example.com")},
+		{Name: "number account_id", Paths: map[string]string{"/2.0/user": `{"account_id":1234567890123456789}`, "/2.0/user/emails": `{"values":[]}`}, ID: "1234567890123456789"},
+		{Name: "float account_id", Body: `{"account_id":1.5}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Paths: map[string]string{"/2.0/user": `{"account_id":"1","username":5,"display_name":[],"created_on":1,"links":{"avatar":"https://example.com/a.png","html":[]}}`, "/2.0/user/emails": `{}`}, ID: "1", Check: func(t *testing.T, user *oauth.User) {
+			if user.Username != "" || user.Avatar != "" || user.Link != "" || user.Created != nil {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "is_confirmed as a string", Paths: body(`{"values":[{"email":"a@example.com","is_confirmed":"true"}]}`), ID: "557058:c0b72ad0", Check: emails("a@example.com")},
+		{Name: "values as an object", Paths: body(`{"values":{"email":"a@example.com","is_confirmed":true}}`), ID: "557058:c0b72ad0", Check: emails()},
+		{Name: "values with a missing email", Paths: body(`{"values":[{"is_confirmed":true},{"email":5},{"email":"b@example.com","is_confirmed":true}]}`), ID: "557058:c0b72ad0", Check: emails("b@example.com verified")},
+		{Name: "emails not JSON", Paths: map[string]string{"/2.0/user": user, "/2.0/user/emails": `<html>oops</html>`}, ID: "557058:c0b72ad0", Check: emails()},
+		{Name: "emails error with status 200", Paths: body(`{"error":{"message":"Access token expired."}}`), ID: "557058:c0b72ad0", Check: emails()},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "error with status 200", Body: `{"error":"invalid_token","error_description":"Access token expired."}`, Err: oauth.ErrTokenInvalid},
+	})
+}
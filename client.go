@@ -17,6 +17,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"golang.org/x/net/context/ctxhttp"
 )
@@ -36,6 +37,18 @@ type (
 		ClientIDKey     string   `json:"client_id_key,omitempty"`
 		ClientSecretKey string   `json:"client_secret_key,omitempty"`
 		TokenHeader     string   `json:"token_header,omitempty"`
+		PKCE            string   `json:"pkce,omitempty"`
+		Issuer          string   `json:"issuer,omitempty"`
+		JWKSURL         string   `json:"jwks_url,omitempty"`
+		UserInfoURL     string   `json:"userinfo_url,omitempty"`
+		Scopes          []string `json:"scopes,omitempty"`
+		AuthMethods     []string `json:"auth_methods,omitempty"`
+		DeviceURL       string   `json:"device_url,omitempty"`
+		IntrospectURL   string   `json:"introspect_url,omitempty"`
+		// ErrorCodes maps native error codes of the provider to kinds such as token_expired or rate_limited
+		ErrorCodes map[string]string `json:"error_codes,omitempty"`
+		// IDTokenAlgs are the id_token algorithms accepted instead of IDTokenAlgorithms, HS256 signed with the client secret is only accepted when listed
+		IDTokenAlgs []string `json:"id_token_algs,omitempty"`
 	}
 
 	Config struct {
@@ -44,6 +57,15 @@ type (
 		ClientSecret string   `json:"client_secret"`
 		Scopes       []string `json:"scopes"`
 		RedirectURI  string   `json:"redirect_uri"`
+		AuthMethod   string   `json:"auth_method,omitempty"`
+		PrivateKey   string   `json:"private_key,omitempty"`
+		KeyID        string   `json:"key_id,omitempty"`
+
+		IntrospectionCache *IntrospectionCache `json:"-"`
+		Retry              *RetryPolicy        `json:"-"`
+		Log                *LogOptions         `json:"-"`
+		Instrument         Instrument          `json:"-"`
+		Propagator         Propagator          `json:"-"`
 	}
 
 	Client interface {
@@ -56,10 +78,14 @@ type (
 		AccessToken(ctx context.Context, values url.Values) (token *Token, err error)
 		PassowrdToken(ctx context.Context, values url.Values) (token *Token, err error)
 		ClientCredentialsToken(ctx context.Context, values url.Values) (token *Token, err error)
+		JWTBearerToken(ctx context.Context, claims map[string]interface{}, key interface{}, values url.Values) (token *Token, err error)
 		RefreshToken(ctx context.Context, oldToken *Token, values url.Values) (newToken *Token, err error)
 		RevokeToken(ctx context.Context, token *Token, values url.Values) (err error)
+		DeviceAuthorize(ctx context.Context, values url.Values) (device *DeviceAuthorization, err error)
+		DeviceToken(ctx context.Context, device *DeviceAuthorization, values url.Values) (token *Token, err error)
 		Signature(req *http.Request, token *Token, values url.Values) (err error)
 		Response(ctx context.Context, httpClient *http.Client, req *http.Request) (data map[string]interface{}, err error)
+		ResponseInto(ctx context.Context, httpClient *http.Client, req *http.Request, v interface{}) (data map[string]interface{}, err error)
 		User(ctx context.Context, token *Token) (user *User, err error)
 	}
 
@@ -71,6 +97,8 @@ type (
 )
 
 var ContextHTTPClient = "OAUTH_CONTEXT_HTTP_CLIENT"
+
+// DEBUG logs requests and responses with redaction to stdout for clients without Config.Log
 var DEBUG = false
 
 var regexpCallback = regexp.MustCompile("^[0-9a-zA-Z._]+\\((.*)\\);?$")
@@ -80,14 +108,57 @@ func (c *Config) Name() string {
 }
 
 func (c *Config) Response(ctx context.Context, httpClient *http.Client, req *http.Request) (data map[string]interface{}, err error) {
+	data, _, err = c.response(ctx, httpClient, req)
+	return
+}
+
+// ResponseInto handles the response like Response and decodes it into v, data is returned as well for User.Raw.
+// Like the map of Response, a field of v whose JSON type does not match is left zero instead of failing the whole response, callers check the fields they require
+func (c *Config) ResponseInto(ctx context.Context, httpClient *http.Client, req *http.Request, v interface{}) (data map[string]interface{}, err error) {
+	var body []byte
+	if data, body, err = c.response(ctx, httpClient, req); err != nil {
+		return
+	}
+	if len(body) == 0 || body[0] != '{' {
+		// xml and form bodies are decoded through data
+		if body, err = json.Marshal(data); err != nil {
+			return
+		}
+	}
+	if e := json.Unmarshal(body, v); e != nil {
+		if _, ok := e.(*json.UnmarshalTypeError); ok {
+			// encoding/json fills every other field before reporting a mismatched one
+			return
+		}
+		err = &Error{
+			Message: "unexpected response: " + e.Error(),
+			Status:  http.StatusBadGateway,
+			Code:    "unexpected_response",
+			Raw:     data,
+			Err:     e,
+		}
+	}
+	return
+}
+
+func (c *Config) response(ctx context.Context, httpClient *http.Client, req *http.Request) (data map[string]interface{}, body []byte, err error) {
 	var res *http.Response
 
+	ctx, attempts := withAttempts(ctx)
+	ctx, kind := withErrorKind(ctx)
 	if res, err = ctxhttp.Do(ctx, httpClient, req); err != nil {
+		if n := int(atomic.LoadInt32(attempts)); n > 1 {
+			err = &Error{
+				Message:  err.Error(),
+				Status:   http.StatusBadGateway,
+				Attempts: n,
+				Err:      err,
+			}
+		}
 		return
 	}
 	defer res.Body.Close()
 
-	var body []byte
 	body, err = ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
 	if err != nil {
 		err = fmt.Errorf("Cannot fetch token: %v", err)
@@ -165,7 +236,10 @@ func (c *Config) Response(ctx context.Context, httpClient *http.Client, req *htt
 			}
 		}
 	default:
-		if err = json.Unmarshal(body, &data); err != nil {
+		// numbers are kept as json.Number, 64 bit ids lose digits as float64
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err = decoder.Decode(&data); err != nil {
 			return
 		}
 	}
@@ -180,27 +254,35 @@ func (c *Config) Response(ctx context.Context, httpClient *http.Client, req *htt
 			continue
 		}
 		var message string
-		switch val.(type) {
+		switch v := val.(type) {
 		case string:
-			message = val.(string)
-		case float64, float32:
-			val := val.(float64)
-			if val != 0 {
-				message = fmt.Sprintf("oauth error: %s: %s", name, strconv.FormatFloat(val, 'f', 0, 64))
+			if !zeroNumber(v) {
+				message = v
+			}
+		case json.Number:
+			if !zeroNumber(v.String()) {
+				message = fmt.Sprintf("oauth error: %s: %s", name, v)
 			}
-		case int, int64, int32:
-			val := val.(int64)
-			if val != 0 {
-				message = fmt.Sprintf("oauth error: %s: %d", name, val)
+		case map[string]interface{}:
+			if message, _ = v["message"].(string); message == "" {
+				message = fmt.Sprintf("oauth error: %s: %v", name, v)
 			}
 		default:
-			message = fmt.Sprintf("oauth error: %s: %v", name, val)
+			message = fmt.Sprintf("oauth error: %s: %v", name, v)
 		}
 		if message != "" {
 			if status < 400 {
 				status = 400
 			}
-			err = NewError(message, status)
+			e := &Error{
+				Message:  message,
+				Status:   status,
+				Header:   res.Header,
+				Attempts: int(atomic.LoadInt32(attempts)),
+			}
+			c.errorDetails(e, data)
+			reportErrorKind(kind, e, res.StatusCode)
+			err = e
 			return
 		}
 	}
@@ -208,12 +290,135 @@ func (c *Config) Response(ctx context.Context, httpClient *http.Client, req *htt
 		if status < 400 {
 			status = 400
 		}
-		err = NewError(fmt.Sprintf("Status code error: %d", res.StatusCode), status)
+		e := &Error{
+			Message:  fmt.Sprintf("Status code error: %d", res.StatusCode),
+			Status:   status,
+			Header:   res.Header,
+			Attempts: int(atomic.LoadInt32(attempts)),
+		}
+		c.errorDetails(e, data)
+		reportErrorKind(kind, e, res.StatusCode)
+		err = e
 		return
 	}
 	return
 }
 
+// errorDetails fills the structured fields of e from the error response, RFC 6750 resource servers put them in WWW-Authenticate
+func (c *Config) errorDetails(e *Error, data map[string]interface{}) {
+	switch v := data["error"].(type) {
+	case string:
+		e.Code = v
+	case map[string]interface{}:
+		// facebook {"error": {"message": "...", "type": "OAuthException", "code": 190}}
+		e.Description, _ = v["message"].(string)
+		if code, ok := v["code"]; ok {
+			e.NativeCode = fmt.Sprint(code)
+		}
+	case json.Number:
+		// qq {"error": 100016, "error_description": "..."}
+		e.NativeCode = v.String()
+	}
+	if v, ok := data["errors"].([]interface{}); ok && len(v) != 0 {
+		// twitter {"errors": [{"code": 89, "message": "Invalid or expired token."}]}
+		if first, ok := v[0].(map[string]interface{}); ok {
+			e.Description, _ = first["message"].(string)
+			if code, ok := first["code"]; ok {
+				e.NativeCode = fmt.Sprint(code)
+			}
+		}
+	}
+	if v, ok := data["error_description"].(string); ok {
+		e.Description = v
+	}
+	if v, ok := data["error_uri"].(string); ok {
+		e.URI = v
+	}
+	for _, name := range c.Endpoint.Errors {
+		switch v := data[name].(type) {
+		case string:
+			if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+				// codes of form and xml bodies are strings
+				if !zeroNumber(v) && e.NativeCode == "" {
+					e.NativeCode = v
+				}
+			} else if e.Description == "" {
+				e.Description = v
+			}
+		case json.Number:
+			if !zeroNumber(v.String()) && e.NativeCode == "" {
+				e.NativeCode = v.String()
+			}
+		}
+	}
+
+	if kind, ok := c.Endpoint.ErrorCodes[e.NativeCode]; ok && e.NativeCode != "" {
+		e.Code = kind
+		if status, ok := kindStatus[kind]; ok {
+			e.Status = status
+		}
+	}
+
+	if e.Code == "" && e.Header != nil {
+		params := authenticateParams(e.Header.Get("WWW-Authenticate"))
+		e.Code = params["error"]
+		if e.Description == "" {
+			e.Description = params["error_description"]
+		}
+		if e.URI == "" {
+			e.URI = params["error_uri"]
+		}
+	}
+}
+
+// reportErrorKind gives the Event of the response the resolved code, an error sent with status 200 would otherwise count as a success
+func reportErrorKind(kind *atomic.Value, e *Error, status int) {
+	if code := canonicalCode(e.Code); code != "" {
+		kind.Store(code)
+	} else if status < 400 {
+		kind.Store("client_error")
+	}
+}
+
+// zeroNumber reports whether s is a number equal to zero, qq sends "ret": 0 on success
+func zeroNumber(s string) bool {
+	f, err := strconv.ParseFloat(s, 64)
+	return err == nil && f == 0
+}
+
+func authenticateParams(header string) (params map[string]string) {
+	params = map[string]string{}
+	if i := strings.IndexByte(header, ' '); i != -1 {
+		header = header[i+1:]
+	}
+	for header != "" {
+		i := strings.IndexByte(header, '=')
+		if i == -1 {
+			break
+		}
+		key := strings.TrimSpace(header[:i])
+		header = strings.TrimLeft(header[i+1:], " ")
+		var value string
+		if strings.HasPrefix(header, "\"") {
+			end := strings.IndexByte(header[1:], '"')
+			if end == -1 {
+				break
+			}
+			value = header[1 : end+1]
+			header = header[end+2:]
+		} else if end := strings.IndexByte(header, ','); end != -1 {
+			value = header[:end]
+			header = header[end:]
+		} else {
+			value = header
+			header = ""
+		}
+		params[key] = strings.TrimSpace(value)
+		header = strings.TrimLeft(header, ", ")
+	}
+	return
+}
+
 func (c *Config) User(ctx context.Context, token *Token) (user *User, err error) {
 	err = NewError("not support", 500)
 	return
@@ -243,6 +448,29 @@ func MergeValues(deleteEmpty bool, values url.Values, merges ...url.Values) url.
 }
 
 func HTTPClient(ctx context.Context, client Client, token *Token) (httpClient *http.Client) {
+	return newHTTPClient(ctx, &Transport{
+		Client: client,
+		Token:  token,
+	})
+}
+
+// UnsignedHTTPClient sends requests without authentication, such as discovery and JWKS fetches, through the Retry, Log and Instrument of client. client may be nil
+func UnsignedHTTPClient(ctx context.Context, client Client) (httpClient *http.Client) {
+	return newHTTPClient(ctx, &Transport{
+		Client:   client,
+		Unsigned: true,
+	})
+}
+
+// SourceHTTPClient signs requests with tokens from source, refreshing them as needed
+func SourceHTTPClient(ctx context.Context, client Client, source TokenSource) (httpClient *http.Client) {
+	return newHTTPClient(ctx, &Transport{
+		Client: client,
+		Source: source,
+	})
+}
+
+func newHTTPClient(ctx context.Context, transport *Transport) (httpClient *http.Client) {
 	httpClient = http.DefaultClient
 	if ctx != nil {
 		if v, ok := ctx.Value(ContextHTTPClient).(*http.Client); ok {
@@ -262,11 +490,14 @@ func HTTPClient(ctx context.Context, client Client, token *Token) (httpClient *h
 		}
 	}
 
-	httpClient.Transport = &Transport{
-		Parent: httpClient.Transport,
-		Client: client,
-		Token:  token,
+	if c, ok := transport.Client.(configurer); ok {
+		transport.Retry = c.config().Retry
+		transport.Log = c.config().Log
+		transport.Instrument = c.config().Instrument
+		transport.Propagator = c.config().Propagator
 	}
+	transport.Parent = httpClient.Transport
+	httpClient.Transport = transport
 	return
 }
 
@@ -283,7 +514,16 @@ func RandString(n int) string {
 }
 func FormatLocale(locale string) string {
 	locale = strings.Replace(locale, "_", "-", -1)
-	split := strings.Split(locale, "-")
+	// provider values such as "-us-x" have empty segments
+	split := make([]string, 0, 4)
+	for _, s := range strings.Split(locale, "-") {
+		if s != "" {
+			split = append(split, s)
+		}
+	}
+	if len(split) == 0 {
+		return ""
+	}
 
 	split[0] = strings.ToLower(split[0])
 	switch len(split) {
@@ -292,14 +532,10 @@ func FormatLocale(locale string) string {
 	case 2:
 		split[1] = strings.ToUpper(split[1])
 	case 3:
-		if len(split[1]) > 0 {
-			split[1] = strings.ToUpper(split[1]) + strings.ToLower(split[0][1:])
-		}
+		split[1] = strings.ToUpper(split[1][:1]) + strings.ToLower(split[1][1:])
 		split[2] = strings.ToUpper(split[2])
 	default:
-		if len(split[1]) > 0 {
-			split[1] = strings.ToUpper(split[1]) + strings.ToLower(split[0][1:])
-		}
+		split[1] = strings.ToUpper(split[1][:1]) + strings.ToLower(split[1][1:])
 		split[2] = strings.ToUpper(split[2])
 		split[3] = strings.ToUpper(split[3])
 	}
@@ -0,0 +1,78 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseIntoLenient(t *testing.T) {
+	type profile struct {
+		ID      ID     `json:"id"`
+		Name    string `json:"name"`
+		Picture struct {
+			URL string `json:"url"`
+		} `json:"picture"`
+		Verified bool `json:"verified"`
+	}
+	tests := []struct {
+		name string
+		body string
+		id   ID
+		user string
+	}{
+		{"string id", `{"id":"1234567890123456789","name":"a"}`, "1234567890123456789", "a"},
+		{"integer id", `{"id":1234567890123456789,"name":"a"}`, "1234567890123456789", "a"},
+		{"fraction id", `{"id":1.5,"name":"a"}`, "", "a"},
+		{"object id", `{"id":{"a":1},"name":"a"}`, "", "a"},
+		{"null id", `{"id":null,"name":"a"}`, "", "a"},
+		{"numeric name", `{"id":"1","name":5}`, "1", ""},
+		{"string picture", `{"id":"1","name":"a","picture":"https://example.com/a.png","verified":"yes"}`, "1", "a"},
+		{"array body", `[{"id":"1"}]`, "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			req, err := http.NewRequest("GET", server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res profile
+			if _, err = (&Config{}).ResponseInto(context.Background(), http.DefaultClient, req, &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.ID != test.id || res.Name != test.user {
+				t.Fatalf("got id %q name %q, want %q %q", res.ID, res.Name, test.id, test.user)
+			}
+		})
+	}
+}
+
+func TestFormatLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"", ""},
+		{"EN", "en"},
+		{"en_us", "en-US"},
+		{"zh-hans-cn", "zh-Hans-CN"},
+		{"sr-latn-rs-x", "sr-Latn-RS-X"},
+		{"-us-x", "us-X"},
+		{"_a_b", "a-B"},
+		{"-a-b-c", "a-B-C"},
+		{"--", ""},
+		{"en--us", "en-US"},
+	}
+	for _, test := range tests {
+		if got := FormatLocale(test.locale); got != test.want {
+			t.Errorf("FormatLocale(%q) = %q, want %q", test.locale, got, test.want)
+		}
+	}
+}
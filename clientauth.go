@@ -0,0 +1,113 @@
+package oauth
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client authentication methods
+// https://openid.net/specs/openid-connect-core-1_0.html#ClientAuthentication
+const (
+	AuthMethodBasic         = "client_secret_basic"
+	AuthMethodPost          = "client_secret_post"
+	AuthMethodSecretJWT     = "client_secret_jwt"
+	AuthMethodPrivateKeyJWT = "private_key_jwt"
+	AuthMethodNone          = "none"
+)
+
+const ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// ClientAssertionTTL is the lifetime of client assertions
+var ClientAssertionTTL = 5 * time.Minute
+
+func (c *Config) authMethod() string {
+	if c.AuthMethod != "" {
+		return c.AuthMethod
+	}
+	if c.Endpoint.ClientHeader == "Basic" {
+		return AuthMethodBasic
+	}
+	return AuthMethodPost
+}
+
+// SigningKey returns the key used by private_key_jwt and the jwt bearer grant
+func (c *Config) SigningKey() (key interface{}, err error) {
+	if c.PrivateKey == "" {
+		err = NewError("Config.PrivateKey is empty", 500)
+		return
+	}
+	key, err = ParsePrivateKey([]byte(c.PrivateKey))
+	return
+}
+
+// ClientAssertion builds the RFC 7523 client assertion for the client_secret_jwt or private_key_jwt method
+func (c *Config) ClientAssertion(method string, audience string) (assertion string, err error) {
+	var key interface{}
+	switch method {
+	case AuthMethodSecretJWT:
+		if c.ClientSecret == "" {
+			err = NewError("Config.ClientSecret is empty", 500)
+			return
+		}
+		key = []byte(c.ClientSecret)
+	case AuthMethodPrivateKeyJWT:
+		if key, err = c.SigningKey(); err != nil {
+			return
+		}
+	default:
+		err = NewError("Client authentication method is not a jwt: "+method, 500)
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss": c.ClientID,
+		"sub": c.ClientID,
+		"aud": audience,
+		"jti": RandString(32),
+		"iat": now.Unix(),
+		"exp": now.Add(ClientAssertionTTL).Unix(),
+	}
+	assertion, err = SignJWT(JWTHeader{Kid: c.KeyID}, claims, key)
+	return
+}
+
+func (c *Config) clientAuthentication(req *http.Request, values url.Values) (url.Values, error) {
+	ClientIDKey := c.Endpoint.ClientIDKey
+	ClientSecretKey := c.Endpoint.ClientSecretKey
+	if ClientIDKey == "" {
+		ClientIDKey = "client_id"
+	}
+	if ClientSecretKey == "" {
+		ClientSecretKey = "client_secret"
+	}
+
+	switch method := c.authMethod(); method {
+	case AuthMethodBasic:
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	case AuthMethodPost:
+		values = MergeValues(false, values, url.Values{ClientIDKey: {c.ClientID}, ClientSecretKey: {c.ClientSecret}})
+	case AuthMethodNone:
+		values = MergeValues(false, values, url.Values{ClientIDKey: {c.ClientID}})
+	case AuthMethodSecretJWT, AuthMethodPrivateKeyJWT:
+		// the audience is the token endpoint
+		audience := c.Endpoint.AccessTokenURL
+		if audience == "" {
+			audience = strings.Split(req.URL.String(), "?")[0]
+		}
+		assertion, err := c.ClientAssertion(method, audience)
+		if err != nil {
+			return values, err
+		}
+		values = MergeValues(false, values, url.Values{
+			ClientIDKey:             {c.ClientID},
+			"client_assertion_type": {ClientAssertionType},
+			"client_assertion":      {assertion},
+		})
+	default:
+		return values, NewError("Client authentication method not support: "+method, 500)
+	}
+	return values, nil
+}
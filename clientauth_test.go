@@ -0,0 +1,122 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestClientAssertion(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+
+	var form url.Values
+	var basic bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		_, _, basic = r.BasicAuth()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"a1","token_type":"bearer"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		method string
+		alg    string
+		verify interface{}
+	}{
+		{AuthMethodPrivateKeyJWT, "ES256", &key.PublicKey},
+		{AuthMethodSecretJWT, "HS256", []byte("secret")},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.method, func(t *testing.T) {
+			client := &OAuth2{Config: Config{
+				ClientID:     "client",
+				ClientSecret: "secret",
+				PrivateKey:   privateKey,
+				KeyID:        "k1",
+				AuthMethod:   test.method,
+				Endpoint:     Endpoint{AccessTokenURL: server.URL + "/token", ClientHeader: "Basic"},
+			}}
+
+			jtis := map[string]bool{}
+			for i := 0; i < 2; i++ {
+				before := time.Now().Unix()
+				if _, err := client.ClientCredentialsToken(context.Background(), nil); err != nil {
+					t.Fatal(err)
+				}
+				if basic || form.Get("client_secret") != "" {
+					t.Fatal("client secret sent along with the assertion")
+				}
+				if form.Get("client_id") != "client" || form.Get("client_assertion_type") != ClientAssertionType {
+					t.Fatalf("token request %v", form)
+				}
+
+				jwt, err := ParseJWT(form.Get("client_assertion"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = jwt.Verify(test.verify); err != nil {
+					t.Fatal(err)
+				}
+				if jwt.Header.Alg != test.alg || jwt.Header.Kid != "k1" {
+					t.Fatalf("header = %+v", jwt.Header)
+				}
+				claims := jwt.Claims
+				if claims["iss"] != "client" || claims["sub"] != "client" {
+					t.Fatalf("iss = %v, sub = %v, want the client id", claims["iss"], claims["sub"])
+				}
+				// the audience is the token endpoint
+				if claims["aud"] != server.URL+"/token" {
+					t.Fatalf("aud = %v", claims["aud"])
+				}
+				iat, _ := claims["iat"].(json.Number).Int64()
+				exp, _ := claims["exp"].(json.Number).Int64()
+				if iat < before || iat > time.Now().Unix() || exp-iat != int64(ClientAssertionTTL/time.Second) {
+					t.Fatalf("iat = %d, exp = %d", iat, exp)
+				}
+				jti, _ := claims["jti"].(string)
+				if jti == "" || jtis[jti] {
+					t.Fatalf("jti %q is empty or reused", jti)
+				}
+				jtis[jti] = true
+			}
+		})
+	}
+}
+
+func TestClientAssertionErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		method string
+	}{
+		{"no private key", Config{ClientID: "client"}, AuthMethodPrivateKeyJWT},
+		{"bad private key", Config{ClientID: "client", PrivateKey: "key"}, AuthMethodPrivateKeyJWT},
+		{"no client secret", Config{ClientID: "client"}, AuthMethodSecretJWT},
+		{"not a jwt method", Config{ClientID: "client", ClientSecret: "secret"}, AuthMethodBasic},
+	}
+	for _, test := range tests {
+		if assertion, err := test.config.ClientAssertion(test.method, "https://example.com/token"); err == nil {
+			t.Fatalf("%s: ClientAssertion() = %q", test.name, assertion)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+// Package config loads provider configurations from JSON, YAML or TOML files.
+//
+// The top level maps an entry name to a configuration. The provider defaults
+// to the entry name and must be registered, so the provider packages in use
+// have to be imported. Any Endpoint field may be overridden under "endpoint",
+// pkce: none turns off the PKCE a provider enables by default. Strings may
+// reference the environment with ${NAME} or ${NAME:-default} and secrets may
+// be read from client_secret_file and private_key_file.
+//
+//	google:
+//	  client_id: 392542345422.apps.googleusercontent.com
+//	  client_secret_file: /run/secrets/google
+//	  scopes: [openid, email]
+//	  redirect_uri: https://${HOST}/callback/google
+//
+// YAML and TOML are read by a small parser for the subset config files need,
+// every value is a string or a list of strings.
+//
+// YAML: block mappings indented with spaces, "- item" and [a, b] lists of
+// scalars, plain, 'single' and "double" quoted scalars, # comments and a
+// leading ---. Anchors, aliases, merge keys, tags, | and > multi-line
+// strings, {flow} mappings, lists of mappings and tab indentation are
+// rejected with an error.
+//
+// TOML: [table] and [table.sub] headers of bare keys, key = value with
+// basic and literal strings, single line arrays and # comments. Arrays of
+// tables, inline tables, dotted keys, multi-line strings and arrays spanning
+// lines are rejected with an error.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/otamoe/oauth-client"
+)
+
+type (
+	Entry struct {
+		oauth.Config
+		Name             string `json:"-"`
+		Provider         string `json:"provider,omitempty"`
+		ClientSecretFile string `json:"client_secret_file,omitempty"`
+		PrivateKeyFile   string `json:"private_key_file,omitempty"`
+		version          string
+	}
+
+	FieldError struct {
+		Field   string
+		Message string
+	}
+
+	Errors []*FieldError
+)
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "config: " + strings.Join(messages, "; ")
+}
+
+func (e *Errors) add(field string, format string, args ...interface{}) {
+	*e = append(*e, &FieldError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Load reads path, the format is chosen by extension. Relative secret files are resolved against the directory of path
+func Load(path string) (entries map[string]*Entry, err error) {
+	var b []byte
+	if b, err = ioutil.ReadFile(path); err != nil {
+		return
+	}
+	entries, err = parse(b, Format(path, b), filepath.Dir(path))
+	return
+}
+
+func Parse(b []byte, format string) (entries map[string]*Entry, err error) {
+	return parse(b, format, "")
+}
+
+// Format returns the format of a file by extension, sniffing content when the extension is unknown
+func Format(path string, b []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	}
+	b = bytes.TrimSpace(b)
+	switch {
+	case bytes.HasPrefix(b, []byte("{")):
+		return FormatJSON
+	case bytes.HasPrefix(b, []byte("[")):
+		return FormatTOML
+	}
+	return FormatYAML
+}
+
+func parse(b []byte, format string, dir string) (entries map[string]*Entry, err error) {
+	var tree map[string]interface{}
+	switch format {
+	case FormatJSON:
+		err = json.Unmarshal(b, &tree)
+	case FormatYAML:
+		tree, err = parseYAML(b)
+	case FormatTOML:
+		tree, err = parseTOML(b)
+	default:
+		err = fmt.Errorf("config: format not support: %s", format)
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("config: %s: %v", format, err)
+		return
+	}
+
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs Errors
+	entries = make(map[string]*Entry, len(names))
+	for _, name := range names {
+		if entry := decodeEntry(name, tree[name], dir, &errs); entry != nil {
+			entries[name] = entry
+		}
+	}
+	if len(errs) != 0 {
+		err = errs
+	}
+	return
+}
+
+func decodeEntry(name string, value interface{}, dir string, errs *Errors) (entry *Entry) {
+	if _, ok := value.(map[string]interface{}); !ok {
+		errs.add(name, "must be a table of settings")
+		return
+	}
+	count := len(*errs)
+	value = interpolate(value, name, errs)
+	if len(*errs) != count {
+		return
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		errs.add(name, "%v", err)
+		return
+	}
+	entry = &Entry{
+		Name: name,
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(entry); err != nil {
+		if e, ok := err.(*json.UnmarshalTypeError); ok {
+			errs.add(name+"."+e.Field, "must be %s", e.Type)
+		} else {
+			errs.add(name, "%s", strings.TrimPrefix(err.Error(), "json: "))
+		}
+		return nil
+	}
+
+	if entry.ClientSecretFile != "" {
+		if entry.ClientSecret != "" {
+			errs.add(name+".client_secret_file", "client_secret is also set")
+		} else if entry.ClientSecret, err = readSecret(dir, entry.ClientSecretFile); err != nil {
+			errs.add(name+".client_secret_file", "%v", err)
+		}
+	}
+	if entry.PrivateKeyFile != "" {
+		if entry.PrivateKey != "" {
+			errs.add(name+".private_key_file", "private_key is also set")
+		} else if entry.PrivateKey, err = readSecret(dir, entry.PrivateKeyFile); err != nil {
+			errs.add(name+".private_key_file", "%v", err)
+		}
+	}
+
+	if entry.Provider == "" {
+		entry.Provider = name
+	}
+	if provider, ok := oauth.LookupProvider(entry.Provider); ok {
+		entry.Endpoint = oauth.MergeEndpoint(provider.Endpoint, entry.Endpoint)
+		entry.version = provider.Version
+	} else {
+		errs.add(name+".provider", "unknown provider %q", entry.Provider)
+		return nil
+	}
+
+	if err = entry.Validate(); err != nil {
+		*errs = append(*errs, err.(Errors)...)
+	}
+	return
+}
+
+func readSecret(dir string, path string) (secret string, err error) {
+	if dir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	var b []byte
+	if b, err = ioutil.ReadFile(path); err != nil {
+		return
+	}
+	secret = strings.TrimSpace(string(b))
+	if secret == "" {
+		err = fmt.Errorf("%s is empty", path)
+	}
+	return
+}
+
+func interpolate(value interface{}, field string, errs *Errors) interface{} {
+	switch v := value.(type) {
+	case string:
+		s, err := Expand(v)
+		if err != nil {
+			errs.add(field, "%v", err)
+		}
+		return s
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[key] = interpolate(val, field+"."+key, errs)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, val := range v {
+			list[i] = interpolate(val, fmt.Sprintf("%s[%d]", field, i), errs)
+		}
+		return list
+	}
+	return value
+}
+
+// Expand replaces ${NAME} and ${NAME:-default} with environment variables, $$ is a literal $. An unset variable without default is an error
+func Expand(s string) (expanded string, err error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+			continue
+		case '{':
+		default:
+			b.WriteByte(s[i])
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end == -1 {
+			err = fmt.Errorf("unterminated ${ in %q", s)
+			return
+		}
+		name := s[i+2 : i+end]
+		var def *string
+		if j := strings.Index(name, ":-"); j != -1 {
+			d := name[j+2:]
+			def = &d
+			name = name[:j]
+		}
+		if name == "" {
+			err = fmt.Errorf("empty variable name in %q", s)
+			return
+		}
+		if val, ok := os.LookupEnv(name); ok && (val != "" || def == nil) {
+			b.WriteString(val)
+		} else if def != nil {
+			b.WriteString(*def)
+		} else {
+			err = fmt.Errorf("environment variable %s is not set", name)
+			return
+		}
+		i += end
+	}
+	expanded = b.String()
+	return
+}
+
+func (e *Entry) Client() (client oauth.Client, err error) {
+	return oauth.NewClient(e.Provider, e.Config)
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	_ "github.com/otamoe/oauth-client/google"
+)
+
+func TestExpand(t *testing.T) {
+	t.Setenv("OAUTH_TEST_HOST", "example.com")
+	t.Setenv("OAUTH_TEST_EMPTY", "")
+	os.Unsetenv("OAUTH_TEST_UNSET")
+	tests := []struct {
+		input string
+		want  string
+		err   string
+	}{
+		{"plain", "plain", ""},
+		{"https://${OAUTH_TEST_HOST}/callback", "https://example.com/callback", ""},
+		{"${OAUTH_TEST_HOST}${OAUTH_TEST_HOST}", "example.comexample.com", ""},
+		{"${OAUTH_TEST_UNSET:-fallback}", "fallback", ""},
+		{"${OAUTH_TEST_HOST:-fallback}", "example.com", ""},
+		// an empty variable takes the default, without default it stays empty
+		{"${OAUTH_TEST_EMPTY:-fallback}", "fallback", ""},
+		{"a${OAUTH_TEST_EMPTY}b", "ab", ""},
+		{"${OAUTH_TEST_UNSET:-}", "", ""},
+		{"$$${OAUTH_TEST_HOST}", "$example.com", ""},
+		{"$HOME and $", "$HOME and $", ""},
+		{"${OAUTH_TEST_UNSET}", "", "OAUTH_TEST_UNSET is not set"},
+		{"${OAUTH_TEST_HOST", "", "unterminated"},
+		{"${}", "", "empty variable name"},
+		{"${:-x}", "", "empty variable name"},
+	}
+	for _, test := range tests {
+		got, err := Expand(test.input)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Expand(%q) error = %v, want %q", test.input, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expand(%q) error = %v", test.input, err)
+		}
+		if got != test.want {
+			t.Fatalf("Expand(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestParseExpand(t *testing.T) {
+	t.Setenv("OAUTH_TEST_HOST", "example.com")
+	t.Setenv("OAUTH_TEST_SECRET", "secret")
+	entries, err := Parse([]byte(`
+google:
+  client_id: client
+  client_secret: ${OAUTH_TEST_SECRET}
+  scopes: [openid, "${OAUTH_TEST_SCOPE:-email}"]
+  redirect_uri: https://${OAUTH_TEST_HOST}/callback/google
+`), FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := entries["google"]
+	if entry.ClientSecret != "secret" || entry.RedirectURI != "https://example.com/callback/google" || !reflect.DeepEqual(entry.Scopes, []string{"openid", "email"}) {
+		t.Fatalf("entry = %+v", entry.Config)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	os.Unsetenv("OAUTH_TEST_UNSET")
+	_, err := Parse([]byte(`{
+		"google": {
+			"client_id": "",
+			"auth_method": "private_key_jwt",
+			"redirect_uri": "https://example.com/callback#done",
+			"scopes": ["openid email", ""],
+			"endpoint": {"access_token_url": "/token", "pkce": "S512", "scope_sep": "x"}
+		},
+		"other": {"provider": "unknown", "client_id": "client"},
+		"env": {"provider": "google", "client_id": "${OAUTH_TEST_UNSET}", "client_secret": "secret"},
+		"list": ["google"]
+	}`), FormatJSON)
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("Parse() error = %#v, want Errors", err)
+	}
+
+	// every problem of every entry is reported at once
+	want := []string{
+		"env.client_id",
+		"google.client_id",
+		"google.endpoint.access_token_url",
+		"google.endpoint.pkce",
+		"google.endpoint.scope_sep",
+		"google.private_key",
+		"google.redirect_uri",
+		"google.scopes[0]",
+		"google.scopes[1]",
+		"list",
+		"other.provider",
+	}
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field
+	}
+	sort.Strings(fields)
+	if !reflect.DeepEqual(fields, want) {
+		t.Fatalf("error fields = %v, want %v\n%v", fields, want, err)
+	}
+	if !strings.HasPrefix(err.Error(), "config: ") || !strings.Contains(err.Error(), "google.endpoint.pkce: unknown method \"S512\"") {
+		t.Fatalf("Error() = %q", err)
+	}
+
+	entry := &Entry{Name: "google"}
+	entry.ClientID = "client"
+	entry.ClientSecret = "secret"
+	entry.version = "2.0"
+	errs, _ = entry.Validate().(Errors)
+	if len(errs) != 2 || errs[0].Field != "google.endpoint.authorize_url" || errs[1].Field != "google.endpoint.access_token_url" {
+		t.Fatalf("Validate() = %v, want the missing authorize_url and access_token_url", errs)
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})))
+
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("secret", "  secret\n")
+	write("key.pem", privateKey+"\n")
+	write("empty", "\n")
+	secret := filepath.Join(dir, "secret")
+
+	// relative files are read from the directory of the config file
+	entries, err := Load(write("oauth.yaml", `
+google:
+  client_id: client
+  client_secret_file: secret
+  private_key_file: key.pem
+  auth_method: private_key_jwt
+absolute:
+  provider: google
+  client_id: client
+  client_secret_file: `+secret+`
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries["google"].ClientSecret != "secret" || entries["google"].PrivateKey != privateKey {
+		t.Fatalf("google secrets = %q, %q", entries["google"].ClientSecret, entries["google"].PrivateKey)
+	}
+	if entries["absolute"].ClientSecret != "secret" {
+		t.Fatalf("absolute client_secret = %q", entries["absolute"].ClientSecret)
+	}
+
+	_, err = Load(write("bad.yaml", `
+both:
+  provider: google
+  client_id: client
+  client_secret: inline
+  client_secret_file: secret
+missing:
+  provider: google
+  client_id: client
+  client_secret_file: missing
+empty:
+  provider: google
+  client_id: client
+  client_secret_file: empty
+`))
+	errs, _ := err.(Errors)
+	fields := map[string]string{}
+	for _, e := range errs {
+		fields[e.Field] = e.Message
+	}
+	if !strings.Contains(fields["both.client_secret_file"], "client_secret is also set") ||
+		!strings.Contains(fields["missing.client_secret_file"], "no such file") ||
+		!strings.Contains(fields["empty.client_secret_file"], "is empty") {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// a secret file that cannot be read leaves the secret missing, Validate still reports it
+	if fields["missing.client_secret"] != "is required" || fields["empty.client_secret"] != "is required" {
+		t.Fatalf("Load() error = %v", err)
+	}
+}
+
+func TestParsePKCENone(t *testing.T) {
+	entries, err := Parse([]byte(`
+google:
+  client_id: client
+  client_secret: secret
+  endpoint:
+    pkce: none
+`), FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := entries["google"].Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorizeURL, data, err := client.Authorize(context.Background(), "state", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorizeURL.Query().Get("code_challenge") != "" || data["code_verifier"] != nil {
+		t.Fatalf("PKCE is on with pkce: none, %s", authorizeURL)
+	}
+
+	if _, err = Parse([]byte(`{"google": {"client_id": "client", "client_secret": "secret", "endpoint": {"pkce": "S512"}}}`), FormatJSON); err == nil {
+		t.Fatal("unknown pkce method is accepted")
+	}
+}
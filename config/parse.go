@@ -0,0 +1,296 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type line struct {
+	number int
+	indent int
+	text   string
+}
+
+// parseYAML reads the block mapping subset of YAML used by config files: nested maps, "- item" and [a, b] lists of scalars, quoted strings and comments
+func parseYAML(b []byte) (data map[string]interface{}, err error) {
+	lines := make([]*line, 0)
+	for i, text := range strings.Split(string(b), "\n") {
+		text = stripComment(strings.TrimRight(text, " \t\r"))
+		if strings.TrimSpace(text) == "" || strings.TrimSpace(text) == "---" {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(text, " "), "\t") {
+			err = fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+			return
+		}
+		trimmed := strings.TrimLeft(text, " ")
+		lines = append(lines, &line{
+			number: i + 1,
+			indent: len(text) - len(trimmed),
+			text:   trimmed,
+		})
+	}
+	var value interface{}
+	if value, _, err = parseYAMLBlock(lines, 0); err != nil {
+		return
+	}
+	if value == nil {
+		data = map[string]interface{}{}
+		return
+	}
+	var ok bool
+	if data, ok = value.(map[string]interface{}); !ok {
+		err = fmt.Errorf("top level is not a mapping")
+	}
+	return
+}
+
+func parseYAMLBlock(lines []*line, start int) (value interface{}, next int, err error) {
+	if start >= len(lines) {
+		return nil, start, nil
+	}
+	indent := lines[start].indent
+
+	if strings.HasPrefix(lines[start].text, "- ") || lines[start].text == "-" {
+		list := make([]interface{}, 0)
+		next = start
+		for next < len(lines) && lines[next].indent == indent && strings.HasPrefix(lines[next].text, "-") {
+			var item interface{}
+			text := strings.TrimSpace(strings.TrimPrefix(lines[next].text, "-"))
+			if isMapping(text) {
+				err = fmt.Errorf("line %d: lists of mappings are not supported", lines[next].number)
+				return
+			}
+			if item, err = yamlScalar(text); err != nil {
+				err = fmt.Errorf("line %d: %v", lines[next].number, err)
+				return
+			}
+			list = append(list, item)
+			next++
+		}
+		value = list
+		return
+	}
+
+	m := map[string]interface{}{}
+	next = start
+	for next < len(lines) && lines[next].indent == indent {
+		l := lines[next]
+		i := strings.Index(l.text, ":")
+		if i <= 0 || (i+1 < len(l.text) && l.text[i+1] != ' ') {
+			err = fmt.Errorf("line %d: expected key: value", l.number)
+			return
+		}
+		key := unquoteKey(strings.TrimSpace(l.text[:i]))
+		if key == "<<" {
+			err = fmt.Errorf("line %d: merge keys are not supported", l.number)
+			return
+		}
+		rest := strings.TrimSpace(l.text[i+1:])
+		next++
+		if rest != "" {
+			if isMapping(rest) {
+				err = fmt.Errorf("line %d: a nested mapping must start on its own line", l.number)
+				return
+			}
+			if m[key], err = yamlScalar(rest); err != nil {
+				err = fmt.Errorf("line %d: %v", l.number, err)
+				return
+			}
+			continue
+		}
+		if next < len(lines) && lines[next].indent > indent {
+			if m[key], next, err = parseYAMLBlock(lines, next); err != nil {
+				return
+			}
+		} else {
+			m[key] = ""
+		}
+	}
+	if next < len(lines) && lines[next].indent > indent {
+		err = fmt.Errorf("line %d: unexpected indentation", lines[next].number)
+		return
+	}
+	value = m
+	return
+}
+
+// yamlScalar rejects YAML scalars outside the subset instead of reading them as plain strings
+func yamlScalar(text string) (value interface{}, err error) {
+	switch {
+	case strings.HasPrefix(text, "&") || strings.HasPrefix(text, "*"):
+		err = fmt.Errorf("anchors and aliases are not supported")
+	case strings.HasPrefix(text, "!"):
+		err = fmt.Errorf("tags are not supported")
+	case strings.HasPrefix(text, "|") || strings.HasPrefix(text, ">"):
+		err = fmt.Errorf("multi-line strings are not supported")
+	case strings.HasPrefix(text, "{"):
+		err = fmt.Errorf("flow mappings are not supported")
+	default:
+		value, err = parseScalar(text)
+	}
+	return
+}
+
+// isMapping reports whether an unquoted scalar is a "key: value" pair
+func isMapping(text string) bool {
+	if text == "" || strings.IndexByte("\"'[{", text[0]) != -1 {
+		return false
+	}
+	return strings.Contains(text, ": ") || strings.HasSuffix(text, ":")
+}
+
+// parseTOML reads the TOML subset used by config files: [table] and [table.sub] headers, key = value with strings and single line arrays
+func parseTOML(b []byte) (data map[string]interface{}, err error) {
+	data = map[string]interface{}{}
+	table := data
+	for i, text := range strings.Split(string(b), "\n") {
+		text = strings.TrimSpace(stripComment(text))
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "[[") {
+			err = fmt.Errorf("line %d: arrays of tables are not supported", i+1)
+			return
+		}
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				err = fmt.Errorf("line %d: malformed table header", i+1)
+				return
+			}
+			table = data
+			for _, name := range strings.Split(text[1:len(text)-1], ".") {
+				if name = strings.TrimSpace(name); name == "" || strings.ContainsAny(name, "\"'") {
+					err = fmt.Errorf("line %d: table names must be bare keys", i+1)
+					return
+				}
+				sub, ok := table[name].(map[string]interface{})
+				if !ok {
+					sub = map[string]interface{}{}
+					table[name] = sub
+				}
+				table = sub
+			}
+			continue
+		}
+		j := strings.Index(text, "=")
+		if j <= 0 {
+			err = fmt.Errorf("line %d: expected key = value", i+1)
+			return
+		}
+		key := strings.TrimSpace(text[:j])
+		if strings.Contains(key, ".") && key[0] != '"' && key[0] != '\'' {
+			err = fmt.Errorf("line %d: dotted keys are not supported", i+1)
+			return
+		}
+		key = unquoteKey(key)
+		value := strings.TrimSpace(text[j+1:])
+		switch {
+		case strings.HasPrefix(value, "{"):
+			err = fmt.Errorf("line %d: inline tables are not supported", i+1)
+		case strings.HasPrefix(value, `"""`) || strings.HasPrefix(value, "'''"):
+			err = fmt.Errorf("line %d: multi-line strings are not supported", i+1)
+		case strings.HasPrefix(value, "[") && !strings.HasSuffix(value, "]"):
+			err = fmt.Errorf("line %d: arrays must be on one line", i+1)
+		}
+		if err != nil {
+			return
+		}
+		if table[key], err = parseScalar(value); err != nil {
+			err = fmt.Errorf("line %d: %v", i+1, err)
+			return
+		}
+	}
+	return
+}
+
+// parseScalar returns a string, or a []interface{} of strings for [a, b]
+func parseScalar(text string) (value interface{}, err error) {
+	if strings.HasPrefix(text, "[") {
+		if !strings.HasSuffix(text, "]") {
+			err = fmt.Errorf("unterminated list")
+			return
+		}
+		list := make([]interface{}, 0)
+		for _, item := range splitList(text[1 : len(text)-1]) {
+			var v interface{}
+			if v, err = parseScalar(item); err != nil {
+				return
+			}
+			list = append(list, v)
+		}
+		value = list
+		return
+	}
+	switch {
+	case len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"':
+		if value, err = strconv.Unquote(text); err != nil {
+			err = fmt.Errorf("malformed string %s", text)
+		}
+	case len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'':
+		inner := text[1 : len(text)-1]
+		if strings.Contains(strings.Replace(inner, "''", "", -1), "'") {
+			err = fmt.Errorf("malformed string %s", text)
+			return
+		}
+		value = strings.Replace(inner, "''", "'", -1)
+	case text != "" && (text[0] == '"' || text[0] == '\''):
+		err = fmt.Errorf("unterminated string %s", text)
+	default:
+		value = text
+	}
+	return
+}
+
+func splitList(text string) (items []string) {
+	var quote byte
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			items = append(items, strings.TrimSpace(text[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(text[start:]); last != "" {
+		items = append(items, last)
+	}
+	return
+}
+
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		}
+	}
+	return text
+}
+
+func unquoteKey(key string) string {
+	if v, err := parseScalar(key); err == nil {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return key
+}
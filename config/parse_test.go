@@ -0,0 +1,124 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type parseTest struct {
+	name  string
+	input string
+	want  map[string]interface{}
+	err   string
+}
+
+func runParseTests(t *testing.T, parse func([]byte) (map[string]interface{}, error), tests []parseTest) {
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parse([]byte(test.input))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	runParseTests(t, parseYAML, []parseTest{
+		{name: "empty", input: "", want: map[string]interface{}{}},
+		{name: "document start", input: "---\na: b\n", want: map[string]interface{}{"a": "b"}},
+		{
+			name:  "nested",
+			input: "google:\n  client_id: id\n  endpoint:\n    api_url: https://example.com\n  empty:\n",
+			want: map[string]interface{}{"google": map[string]interface{}{
+				"client_id": "id",
+				"endpoint":  map[string]interface{}{"api_url": "https://example.com"},
+				"empty":     "",
+			}},
+		},
+		{
+			name:  "block list",
+			input: "scopes:\n  - openid\n  - 'email'\n",
+			want:  map[string]interface{}{"scopes": []interface{}{"openid", "email"}},
+		},
+		{
+			name:  "flow list",
+			input: `scopes: [openid, "a, b", 'c']`,
+			want:  map[string]interface{}{"scopes": []interface{}{"openid", "a, b", "c"}},
+		},
+		{
+			name:  "quoted",
+			input: "a: \"x\\ty\"\nb: 'it''s'\n\"c d\": e\n",
+			want:  map[string]interface{}{"a": "x\ty", "b": "it's", "c d": "e"},
+		},
+		{
+			name:  "comments",
+			input: "# head\na: b # tail\nc: \"d # e\"\nf: 'g # h'\ni: j#k\n",
+			want:  map[string]interface{}{"a": "b", "c": "d # e", "f": "g # h", "i": "j#k"},
+		},
+		{name: "tab indentation", input: "a:\n\tb: c\n", err: "tabs are not allowed"},
+		{name: "anchor", input: "a: &x b\n", err: "anchors and aliases"},
+		{name: "alias", input: "a: *x\n", err: "anchors and aliases"},
+		{name: "merge key", input: "a:\n  <<: b\n", err: "merge keys"},
+		{name: "tag", input: "a: !!str b\n", err: "tags"},
+		{name: "literal block", input: "a: |\n  b\n", err: "multi-line strings"},
+		{name: "folded block", input: "a: >-\n  b\n", err: "multi-line strings"},
+		{name: "flow mapping", input: "a: {b: c}\n", err: "flow mappings"},
+		{name: "list of mappings", input: "a:\n  - b: c\n", err: "lists of mappings"},
+		{name: "inline mapping", input: "a: b: c\n", err: "nested mapping"},
+		{name: "unterminated string", input: "a: \"b\n", err: "unterminated string"},
+		{name: "malformed string", input: "a: 'b' 'c'\n", err: "malformed string"},
+		{name: "bad indentation", input: "a: b\n  c: d\n", err: "unexpected indentation"},
+		{name: "missing colon", input: "a\n", err: "expected key: value"},
+		{name: "top level list", input: "- a\n", err: "top level is not a mapping"},
+	})
+}
+
+func TestParseTOML(t *testing.T) {
+	runParseTests(t, parseTOML, []parseTest{
+		{name: "empty", input: "", want: map[string]interface{}{}},
+		{
+			name:  "tables",
+			input: "[google]\nclient_id = \"id\"\n\n[google.endpoint]\napi_url = 'https://example.com'\n",
+			want: map[string]interface{}{"google": map[string]interface{}{
+				"client_id": "id",
+				"endpoint":  map[string]interface{}{"api_url": "https://example.com"},
+			}},
+		},
+		{
+			name:  "array",
+			input: "[a]\nscopes = [\"openid\", 'email']\n",
+			want:  map[string]interface{}{"a": map[string]interface{}{"scopes": []interface{}{"openid", "email"}}},
+		},
+		{
+			name:  "comments",
+			input: "# head\n[a] # table\nb = \"c # d\" # tail\n",
+			want:  map[string]interface{}{"a": map[string]interface{}{"b": "c # d"}},
+		},
+		{
+			name:  "quoted key",
+			input: "\"a.b\" = \"c\"\n",
+			want:  map[string]interface{}{"a.b": "c"},
+		},
+		{name: "array of tables", input: "[[a]]\nb = \"c\"\n", err: "arrays of tables"},
+		{name: "inline table", input: "a = {b = \"c\"}\n", err: "inline tables"},
+		{name: "dotted key", input: "a.b = \"c\"\n", err: "dotted keys"},
+		{name: "quoted table name", input: "[\"a.b\"]\n", err: "bare keys"},
+		{name: "multi-line basic string", input: "a = \"\"\"\nb\n\"\"\"\n", err: "multi-line strings"},
+		{name: "multi-line literal string", input: "a = '''b'''\n", err: "multi-line strings"},
+		{name: "multi-line array", input: "a = [\n\"b\",\n]\n", err: "arrays must be on one line"},
+		{name: "malformed header", input: "[a\n", err: "malformed table header"},
+		{name: "missing equals", input: "a\n", err: "expected key = value"},
+		{name: "unterminated string", input: "a = \"b\n", err: "unterminated string"},
+	})
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/otamoe/oauth-client"
+)
+
+// Validate checks the entry after the provider endpoint is merged, err is Errors with every problem found
+func (e *Entry) Validate() (err error) {
+	var errs Errors
+	name := e.Name
+	endpoint := e.Endpoint
+
+	if e.ClientID == "" {
+		errs.add(name+".client_id", "is required")
+	}
+
+	switch e.AuthMethod {
+	case "", oauth.AuthMethodBasic, oauth.AuthMethodPost, oauth.AuthMethodSecretJWT:
+		if e.ClientSecret == "" {
+			errs.add(name+".client_secret", "is required")
+		}
+	case oauth.AuthMethodPrivateKeyJWT:
+		if e.PrivateKey == "" {
+			errs.add(name+".private_key", "is required by auth_method %s", e.AuthMethod)
+		}
+	case oauth.AuthMethodNone:
+	default:
+		errs.add(name+".auth_method", "unknown method %q", e.AuthMethod)
+	}
+	if e.PrivateKey != "" {
+		if _, err := oauth.ParsePrivateKey([]byte(e.PrivateKey)); err != nil {
+			errs.add(name+".private_key", "%v", err)
+		}
+	}
+
+	required := []string{"authorize_url", "access_token_url"}
+	if e.version == "1.0" {
+		required = []string{"request_url", "authorize_url", "access_token_url"}
+	}
+	urls := []struct {
+		field string
+		value string
+	}{
+		{"request_url", endpoint.RequestURL},
+		{"authorize_url", endpoint.AuthorizeURL},
+		{"access_token_url", endpoint.AccessTokenURL},
+		{"refresh_token_url", endpoint.RefreshTokenURL},
+		{"revoke_token_url", endpoint.RevokeTokenURL},
+		{"api_url", endpoint.APIURL},
+		{"jwks_url", endpoint.JWKSURL},
+		{"userinfo_url", endpoint.UserInfoURL},
+		{"device_url", endpoint.DeviceURL},
+		{"introspect_url", endpoint.IntrospectURL},
+	}
+	for _, u := range urls {
+		field := name + ".endpoint." + u.field
+		if u.value == "" {
+			for _, r := range required {
+				if r == u.field {
+					errs.add(field, "is required for oauth %s", e.version)
+				}
+			}
+			continue
+		}
+		if msg := checkURL(u.value); msg != "" {
+			errs.add(field, "%s", msg)
+		}
+	}
+
+	switch endpoint.PKCE {
+	case "", oauth.PKCEPlain, oauth.PKCES256, oauth.PKCENone:
+	default:
+		errs.add(name+".endpoint.pkce", "unknown method %q", endpoint.PKCE)
+	}
+
+	if e.RedirectURI != "" {
+		if msg := checkURL(e.RedirectURI); msg != "" {
+			errs.add(name+".redirect_uri", "%s", msg)
+		} else if strings.Contains(e.RedirectURI, "#") {
+			errs.add(name+".redirect_uri", "must not contain a fragment")
+		}
+	}
+
+	sep := endpoint.ScopeSep
+	if sep == "" {
+		sep = " "
+	} else if strings.IndexFunc(sep, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) != -1 {
+		errs.add(name+".endpoint.scope_sep", "must not contain letters or digits")
+	}
+	for i, scope := range e.Scopes {
+		field := name + ".scopes[" + strconv.Itoa(i) + "]"
+		switch {
+		case scope == "":
+			errs.add(field, "is empty")
+		case strings.Contains(scope, sep):
+			errs.add(field, "%q contains the scope separator %q, list each scope separately", scope, sep)
+		case strings.IndexFunc(scope, unicode.IsSpace) != -1:
+			errs.add(field, "%q contains whitespace", scope)
+		}
+	}
+
+	if len(errs) != 0 {
+		err = errs
+	}
+	return
+}
+
+func checkURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil {
+		return strings.TrimPrefix(err.Error(), "parse ")
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "\"" + value + "\" is not an absolute url"
+	}
+	return ""
+}
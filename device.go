@@ -0,0 +1,172 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	DeviceAuthorization struct {
+		DeviceCode              string                 `json:"device_code"`
+		UserCode                string                 `json:"user_code"`
+		VerificationURI         string                 `json:"verification_uri"`
+		VerificationURIComplete string                 `json:"verification_uri_complete,omitempty"`
+		Interval                int                    `json:"interval,omitempty"`
+		Raw                     map[string]interface{} `json:"raw,omitempty"`
+		Created                 *time.Time             `json:"created"`
+		Expired                 *time.Time             `json:"expired,omitempty"`
+	}
+)
+
+// DeviceInterval is the polling interval when the provider does not send one
+var DeviceInterval = 5 * time.Second
+
+// DeviceSlowDown is added to the polling interval on each slow_down response
+var DeviceSlowDown = 5 * time.Second
+
+// https://tools.ietf.org/html/rfc8628#section-3.1
+func (c *OAuth2) DeviceAuthorize(ctx context.Context, values url.Values) (device *DeviceAuthorization, err error) {
+	if c.Endpoint.DeviceURL == "" {
+		err = NewError("Device authorization is not supported", 500)
+		return
+	}
+	now := time.Now()
+
+	defaultValues := url.Values{
+		"scope": {c.scope()},
+	}
+	AppendValues := url.Values{
+		c.clientIDKey(): {c.ClientID},
+	}
+	values = MergeValues(true, nil, defaultValues, values, AppendValues)
+
+	var req *http.Request
+	if req, err = http.NewRequest("POST", c.Endpoint.DeviceURL, strings.NewReader(values.Encode())); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	httpClient := HTTPClient(ctx, c, nil)
+	var raw map[string]interface{}
+	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+		return
+	}
+
+	device = &DeviceAuthorization{
+		Created: &now,
+	}
+	if v, ok := raw["device_code"].(string); ok {
+		device.DeviceCode = v
+	}
+	if v, ok := raw["user_code"].(string); ok {
+		device.UserCode = v
+	}
+	if v, ok := raw["verification_uri"].(string); ok {
+		device.VerificationURI = v
+	} else if v, ok := raw["verification_url"].(string); ok {
+		// google
+		device.VerificationURI = v
+	}
+	if v, ok := raw["verification_uri_complete"].(string); ok {
+		device.VerificationURIComplete = v
+	}
+	if s := rawSeconds(raw["interval"]); s > 0 {
+		device.Interval = int(s)
+	}
+	if s := rawSeconds(raw["expires_in"]); s > 0 {
+		expired := now.Add(time.Duration(s) * time.Second)
+		device.Expired = &expired
+	}
+	if device.DeviceCode == "" || device.UserCode == "" {
+		err = NewError("device_code or user_code is empty", 500)
+		device = nil
+		return
+	}
+
+	delete(raw, "device_code")
+	delete(raw, "user_code")
+	device.Raw = raw
+	return
+}
+
+// DeviceToken polls the token endpoint until the user approves or denies the device, the device code expires or ctx is done
+// https://tools.ietf.org/html/rfc8628#section-3.4
+func (c *OAuth2) DeviceToken(ctx context.Context, device *DeviceAuthorization, values url.Values) (token *Token, err error) {
+	interval := DeviceInterval
+	if device.Interval > 0 {
+		interval = time.Duration(device.Interval) * time.Second
+	}
+
+	AppendValues := url.Values{
+		"grant_type":    {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code":   {device.DeviceCode},
+		c.clientIDKey(): {c.ClientID},
+	}
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		case <-timer.C:
+		}
+
+		if device.Expired != nil && device.Expired.Before(time.Now()) {
+			err = ErrTokenExpired
+			return
+		}
+
+		token = &Token{}
+		if err = c.RequestToken(ctx, c.Endpoint.AccessTokenURL, token, values, AppendValues); err == nil {
+			return
+		}
+		token = nil
+
+		e, ok := err.(*Error)
+		if !ok {
+			return
+		}
+		switch e.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += DeviceSlowDown
+		case "access_denied":
+			err = ErrDenied
+			return
+		case "expired_token":
+			err = ErrTokenExpired
+			return
+		default:
+			return
+		}
+		timer.Reset(interval)
+	}
+}
+
+func (c *OAuth2) clientIDKey() string {
+	if c.Endpoint.ClientIDKey == "" {
+		return "client_id"
+	}
+	return c.Endpoint.ClientIDKey
+}
+
+func rawSeconds(val interface{}) float64 {
+	switch v := val.(type) {
+	case json.Number:
+		s, _ := v.Float64()
+		return s
+	case float64:
+		return v
+	case string:
+		s, _ := strconv.ParseFloat(v, 64)
+		return s
+	}
+	return 0
+}
@@ -0,0 +1,135 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newDeviceServer answers the token endpoint with bodies in order, the last one is repeated
+func newDeviceServer(t *testing.T, bodies ...string) (*OAuth2, func() []time.Time) {
+	var mu sync.Mutex
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:device_code" || r.FormValue("device_code") != "d1" {
+			t.Errorf("token request %v", r.Form)
+		}
+		mu.Lock()
+		times = append(times, time.Now())
+		body := bodies[0]
+		if len(bodies) > 1 {
+			bodies = bodies[1:]
+		}
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(body, `"error"`) {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	client := &OAuth2{Config: Config{
+		ClientID: "client",
+		Endpoint: Endpoint{AccessTokenURL: server.URL},
+	}}
+	return client, func() []time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Time(nil), times...)
+	}
+}
+
+func setDeviceIntervals(t *testing.T, interval time.Duration, slowDown time.Duration) {
+	oldInterval, oldSlowDown := DeviceInterval, DeviceSlowDown
+	DeviceInterval, DeviceSlowDown = interval, slowDown
+	t.Cleanup(func() {
+		DeviceInterval, DeviceSlowDown = oldInterval, oldSlowDown
+	})
+}
+
+func TestDeviceTokenSlowDown(t *testing.T) {
+	setDeviceIntervals(t, 20*time.Millisecond, 40*time.Millisecond)
+	client, times := newDeviceServer(t,
+		`{"error":"authorization_pending"}`,
+		`{"error":"slow_down"}`,
+		`{"error":"slow_down"}`,
+		`{"access_token":"a1","token_type":"bearer","expires_in":3600}`,
+	)
+
+	start := time.Now()
+	token, err := client.DeviceToken(context.Background(), &DeviceAuthorization{DeviceCode: "d1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "a1" {
+		t.Fatalf("DeviceToken() = %+v", token)
+	}
+
+	requests := times()
+	if len(requests) != 4 {
+		t.Fatalf("%d token requests, want 4", len(requests))
+	}
+	// each slow_down adds DeviceSlowDown to the interval for every later poll
+	want := []time.Duration{20 * time.Millisecond, 20 * time.Millisecond, 60 * time.Millisecond, 100 * time.Millisecond}
+	last := start
+	for i, at := range requests {
+		if gap := at.Sub(last); gap < want[i] {
+			t.Fatalf("request %d sent after %s, want at least %s", i, gap, want[i])
+		}
+		last = at
+	}
+}
+
+func TestDeviceTokenErrors(t *testing.T) {
+	setDeviceIntervals(t, time.Millisecond, time.Millisecond)
+	past := time.Now().Add(-time.Second)
+	tests := []struct {
+		name     string
+		device   *DeviceAuthorization
+		bodies   []string
+		requests int
+		err      error
+	}{
+		{"denied", &DeviceAuthorization{DeviceCode: "d1"}, []string{`{"error":"authorization_pending"}`, `{"error":"access_denied"}`}, 2, ErrDenied},
+		{"expired_token", &DeviceAuthorization{DeviceCode: "d1"}, []string{`{"error":"authorization_pending"}`, `{"error":"expired_token"}`}, 2, ErrTokenExpired},
+		{"expired device code", &DeviceAuthorization{DeviceCode: "d1", Expired: &past}, []string{`{"error":"authorization_pending"}`}, 0, ErrTokenExpired},
+		{"other error", &DeviceAuthorization{DeviceCode: "d1"}, []string{`{"error":"invalid_client"}`}, 1, &Error{Code: "invalid_client"}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			client, times := newDeviceServer(t, test.bodies...)
+			token, err := client.DeviceToken(context.Background(), test.device, nil)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("DeviceToken() error = %v, want %v", err, test.err)
+			}
+			if token != nil {
+				t.Fatalf("DeviceToken() = %+v, want nil", token)
+			}
+			if n := len(times()); n != test.requests {
+				t.Fatalf("%d token requests, want %d", n, test.requests)
+			}
+		})
+	}
+}
+
+func TestDeviceTokenExpiresWhilePolling(t *testing.T) {
+	setDeviceIntervals(t, 10*time.Millisecond, 10*time.Millisecond)
+	client, times := newDeviceServer(t, `{"error":"authorization_pending"}`)
+	expired := time.Now().Add(50 * time.Millisecond)
+	_, err := client.DeviceToken(context.Background(), &DeviceAuthorization{DeviceCode: "d1", Expired: &expired}, nil)
+	if !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("DeviceToken() error = %v, want ErrTokenExpired", err)
+	}
+	if n := len(times()); n == 0 {
+		t.Fatal("no token request before the device code expired")
+	}
+	if time.Now().Before(expired) {
+		t.Fatal("DeviceToken() returned before the device code expired")
+	}
+}
@@ -0,0 +1,180 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context/ctxhttp"
+)
+
+type (
+	Discovery struct {
+		Issuer                            string                 `json:"issuer"`
+		AuthorizationEndpoint             string                 `json:"authorization_endpoint,omitempty"`
+		TokenEndpoint                     string                 `json:"token_endpoint,omitempty"`
+		UserinfoEndpoint                  string                 `json:"userinfo_endpoint,omitempty"`
+		RevocationEndpoint                string                 `json:"revocation_endpoint,omitempty"`
+		DeviceAuthorizationEndpoint       string                 `json:"device_authorization_endpoint,omitempty"`
+		IntrospectionEndpoint             string                 `json:"introspection_endpoint,omitempty"`
+		JWKSURI                           string                 `json:"jwks_uri,omitempty"`
+		ScopesSupported                   []string               `json:"scopes_supported,omitempty"`
+		TokenEndpointAuthMethodsSupported []string               `json:"token_endpoint_auth_methods_supported,omitempty"`
+		CodeChallengeMethodsSupported     []string               `json:"code_challenge_methods_supported,omitempty"`
+		IDTokenSigningAlgValuesSupported  []string               `json:"id_token_signing_alg_values_supported,omitempty"`
+		Raw                               map[string]interface{} `json:"-"`
+		Expired                           *time.Time             `json:"-"`
+	}
+)
+
+// DiscoveryTTL is used when the discovery document has no Cache-Control max-age
+var DiscoveryTTL = 24 * time.Hour
+
+var discoveryCache = struct {
+	sync.Mutex
+	documents map[string]*Discovery
+}{
+	documents: map[string]*Discovery{},
+}
+
+// Discover fetches the OpenID Connect provider configuration of issuer, falling back to the RFC 8414 authorization server metadata. The requests go through the transports of client, which may be nil
+func Discover(ctx context.Context, client Client, issuer string) (discovery *Discovery, err error) {
+	issuer = strings.TrimRight(issuer, "/")
+	now := time.Now()
+
+	discoveryCache.Lock()
+	discovery = discoveryCache.documents[issuer]
+	discoveryCache.Unlock()
+	if discovery != nil && now.Before(*discovery.Expired) {
+		return
+	}
+	discovery = nil
+
+	var u *url.URL
+	if u, err = url.Parse(issuer); err != nil {
+		return
+	}
+	if u.Scheme == "" || u.Host == "" {
+		err = NewError("discovery: issuer is not an absolute url", 500)
+		return
+	}
+
+	// https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderConfig
+	// https://tools.ietf.org/html/rfc8414#section-3
+	urls := []string{
+		issuer + "/.well-known/openid-configuration",
+		u.Scheme + "://" + u.Host + "/.well-known/oauth-authorization-server" + u.Path,
+	}
+	for _, urlString := range urls {
+		if discovery, err = fetchDiscovery(ctx, client, urlString); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	if strings.TrimRight(discovery.Issuer, "/") != issuer {
+		err = NewError(fmt.Sprintf("discovery: issuer does not match: %s", discovery.Issuer), 500)
+		discovery = nil
+		return
+	}
+
+	discoveryCache.Lock()
+	discoveryCache.documents[issuer] = discovery
+	discoveryCache.Unlock()
+	return
+}
+
+func fetchDiscovery(ctx context.Context, client Client, urlString string) (discovery *Discovery, err error) {
+	now := time.Now()
+	var req *http.Request
+	if req, err = http.NewRequest("GET", urlString, nil); err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/json")
+	var res *http.Response
+	if res, err = ctxhttp.Do(ctx, UnsignedHTTPClient(ctx, client), req); err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		err = NewError(fmt.Sprintf("discovery: status code error: %d", res.StatusCode), 502)
+		return
+	}
+
+	var body []byte
+	if body, err = ioutil.ReadAll(io.LimitReader(res.Body, 1<<20)); err != nil {
+		return
+	}
+	discovery = &Discovery{}
+	if err = json.Unmarshal(body, discovery); err != nil {
+		return
+	}
+	if err = json.Unmarshal(body, &discovery.Raw); err != nil {
+		return
+	}
+
+	ttl := DiscoveryTTL
+	for _, directive := range strings.Split(res.Header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(directive)
+		if strings.HasPrefix(directive, "max-age=") {
+			if seconds, e := strconv.Atoi(directive[8:]); e == nil {
+				ttl = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	expired := now.Add(ttl)
+	discovery.Expired = &expired
+	return
+}
+
+func (d *Discovery) Endpoint() (endpoint Endpoint) {
+	endpoint = Endpoint{
+		Issuer:          d.Issuer,
+		AuthorizeURL:    d.AuthorizationEndpoint,
+		AccessTokenURL:  d.TokenEndpoint,
+		RefreshTokenURL: d.TokenEndpoint,
+		RevokeTokenURL:  d.RevocationEndpoint,
+		UserInfoURL:     d.UserinfoEndpoint,
+		DeviceURL:       d.DeviceAuthorizationEndpoint,
+		IntrospectURL:   d.IntrospectionEndpoint,
+		JWKSURL:         d.JWKSURI,
+		APIURL:          d.Issuer,
+		Scopes:          d.ScopesSupported,
+		AuthMethods:     d.TokenEndpointAuthMethodsSupported,
+		TokenHeader:     "Bearer",
+	}
+	if u, err := url.Parse(d.Issuer); err == nil {
+		endpoint.Name = u.Host
+	}
+
+	// client_secret_basic is the default when the list is omitted
+	if len(d.TokenEndpointAuthMethodsSupported) == 0 || containsString(d.TokenEndpointAuthMethodsSupported, "client_secret_basic") {
+		endpoint.ClientHeader = "Basic"
+	}
+
+	if containsString(d.CodeChallengeMethodsSupported, PKCES256) {
+		endpoint.PKCE = PKCES256
+	} else if containsString(d.CodeChallengeMethodsSupported, PKCEPlain) {
+		endpoint.PKCE = PKCEPlain
+	}
+	return
+}
+
+func DiscoverEndpoint(ctx context.Context, client Client, issuer string) (endpoint Endpoint, err error) {
+	var discovery *Discovery
+	if discovery, err = Discover(ctx, client, issuer); err != nil {
+		return
+	}
+	endpoint = discovery.Endpoint()
+	return
+}
@@ -0,0 +1,158 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newDiscoveryServer serves document at path, with {issuer} replaced by the server url
+func newDiscoveryServer(t *testing.T, path string, document func(issuer string) string) (*httptest.Server, *int32) {
+	var count int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		w.Write([]byte(document(server.URL)))
+	}))
+	t.Cleanup(server.Close)
+	return server, &count
+}
+
+func TestDiscover(t *testing.T) {
+	server, count := newDiscoveryServer(t, "/.well-known/openid-configuration", func(issuer string) string {
+		return `{"issuer":"` + issuer + `/","authorization_endpoint":"` + issuer + `/authorize","token_endpoint":"` + issuer + `/token","jwks_uri":"` + issuer + `/jwks","code_challenge_methods_supported":["plain","S256"],"token_endpoint_auth_methods_supported":["client_secret_post"]}`
+	})
+
+	before := time.Now()
+	discovery, err := Discover(context.Background(), nil, server.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if discovery.Expired.Before(before.Add(59*time.Second)) || discovery.Expired.After(time.Now().Add(60*time.Second)) {
+		t.Fatalf("Expired = %s, want the max-age of 60s", discovery.Expired)
+	}
+	endpoint := discovery.Endpoint()
+	if endpoint.AccessTokenURL != server.URL+"/token" || endpoint.JWKSURL != server.URL+"/jwks" || endpoint.PKCE != PKCES256 || endpoint.ClientHeader != "" {
+		t.Fatalf("Endpoint() = %+v", endpoint)
+	}
+
+	if _, err = Discover(context.Background(), nil, server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(count); n != 1 {
+		t.Fatalf("%d discovery requests, want 1", n)
+	}
+}
+
+func TestDiscoverAuthorizationServerMetadata(t *testing.T) {
+	server, _ := newDiscoveryServer(t, "/.well-known/oauth-authorization-server/tenant", func(issuer string) string {
+		return `{"issuer":"` + issuer + `/tenant","token_endpoint":"` + issuer + `/tenant/token"}`
+	})
+	discovery, err := Discover(context.Background(), nil, server.URL+"/tenant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if discovery.TokenEndpoint != server.URL+"/tenant/token" {
+		t.Fatalf("Discover() = %+v", discovery)
+	}
+}
+
+func TestDiscoverIssuerMismatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		issuer func(issuer string) string
+	}{
+		{"other host", func(issuer string) string { return "https://attacker.example.com" }},
+		{"other path", func(issuer string) string { return issuer + "/other" }},
+		{"empty", func(issuer string) string { return "" }},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			server, count := newDiscoveryServer(t, "/.well-known/openid-configuration", func(issuer string) string {
+				return `{"issuer":"` + test.issuer(issuer) + `","token_endpoint":"https://attacker.example.com/token"}`
+			})
+			for i := 0; i < 2; i++ {
+				if discovery, err := Discover(context.Background(), nil, server.URL); err == nil {
+					t.Fatalf("Discover() = %+v, want an issuer mismatch", discovery)
+				}
+			}
+			// a rejected document is not cached
+			if n := atomic.LoadInt32(count); n != 2 {
+				t.Fatalf("%d discovery requests, want 2", n)
+			}
+		})
+	}
+}
+
+func TestDiscoverAndJWKSThroughClient(t *testing.T) {
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); ok || r.URL.Query().Get("client_secret") != "" || r.Header.Get("Authorization") != "" {
+			t.Errorf("%s sent with client authentication", r.URL.Path)
+		}
+		mu.Lock()
+		attempts[r.URL.Path]++
+		n := attempts[r.URL.Path]
+		mu.Unlock()
+		// the first attempt of each document fails, the Retry of the client sends it again
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			w.Write([]byte(`{"issuer":"` + server.URL + `","jwks_uri":"` + server.URL + `/jwks"}`))
+		case "/jwks":
+			w.Write([]byte(`{"keys":[{"kty":"oct","kid":"k1"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	instrument := &recordInstrument{}
+	client := &OAuth2{Config: Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Endpoint:     Endpoint{Name: "example", ClientHeader: "Basic"},
+		Retry:        &RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+		Instrument:   instrument,
+	}}
+
+	endpoint, err := DiscoverEndpoint(context.Background(), client, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := FetchJWKS(context.Background(), client, endpoint.JWKSURL, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jwks.Key("k1") == nil {
+		t.Fatalf("FetchJWKS() = %+v", jwks)
+	}
+
+	instrument.mu.Lock()
+	defer instrument.mu.Unlock()
+	if len(instrument.events) != 4 {
+		t.Fatalf("%d events, want 4", len(instrument.events))
+	}
+	for _, event := range instrument.events {
+		if event.Provider != "example" {
+			t.Fatalf("event provider = %q, want example", event.Provider)
+		}
+	}
+}
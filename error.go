@@ -1,24 +1,116 @@
 package oauth
 
+import (
+	"net/http"
+)
+
 type (
 	Error struct {
 		Message string
 		Status  int
+		// Code is the RFC 6749 error code (invalid_grant, invalid_client, ...) or a library kind such as token_expired
+		Code        string
+		Description string
+		URI         string
+		// NativeCode is the provider specific code, such as errcode of wechat
+		NativeCode string
+		// Header is the response header when the error was returned by the provider
+		Header http.Header
+		// Attempts is the number of requests sent when a Transport retried
+		Attempts int
+		// Raw is the decoded payload of an unexpected_response error
+		Raw map[string]interface{}
+		Err error
 	}
 )
 
-var ErrCancel = NewError("access_cancel", 403)
-var ErrDenied = NewError("access_denied", 403)
-var ErrTokenExpired = NewError("token_expired", 401)
-var ErrTokenInvalid = NewError("token_invalid", 401)
+var ErrCancel = newCodeError("access_cancel", 403)
+var ErrDenied = newCodeError("access_denied", 403)
+var ErrTokenExpired = newCodeError("token_expired", 401)
+var ErrTokenInvalid = newCodeError("token_invalid", 401)
+var ErrRateLimited = newCodeError("rate_limited", 429)
+var ErrRevoked = newCodeError("consent_revoked", 401)
+var ErrUnexpectedResponse = newCodeError("unexpected_response", 502)
+
+// kindStatus is the status of errors whose native code maps to a kind
+var kindStatus = map[string]int{
+	"token_expired":   401,
+	"token_invalid":   401,
+	"consent_revoked": 401,
+	"rate_limited":    429,
+}
+
+// codeAliases maps codes of the same kind to the code of the sentinel
+var codeAliases = map[string]string{
+	"expired_token": "token_expired",
+	"invalid_token": "token_invalid",
+}
 
 func (e Error) Error() string {
 	return e.Message
 }
 
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *Error with the same code, so errors.Is(err, ErrTokenExpired) also matches an expired_token response
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || t.Code == "" || e.Code == "" {
+		return false
+	}
+	return canonicalCode(e.Code) == canonicalCode(t.Code)
+}
+
+// Retryable reports whether the same request may succeed later: rate limits, 5xx responses of the provider and temporary error codes
+func (e *Error) Retryable() bool {
+	switch canonicalCode(e.Code) {
+	case "temporarily_unavailable", "server_error", "slow_down", "rate_limited":
+		return true
+	case "unexpected_response":
+		return false
+	}
+	switch {
+	case e.Status == http.StatusTooManyRequests:
+		return true
+	case e.Status == http.StatusBadGateway || e.Status == http.StatusServiceUnavailable || e.Status == http.StatusGatewayTimeout:
+		return true
+	case e.Status >= 500 && e.Header != nil:
+		// a 500 without header is a local error such as a bad configuration
+		return true
+	}
+	return false
+}
+
+func canonicalCode(code string) string {
+	if alias, ok := codeAliases[code]; ok {
+		return alias
+	}
+	return code
+}
+
 func NewError(message string, status int) error {
 	return &Error{
 		Message: message,
 		Status:  status,
 	}
 }
+
+func newCodeError(code string, status int) error {
+	return &Error{
+		Message: code,
+		Status:  status,
+		Code:    code,
+	}
+}
+
+// UnexpectedResponse is returned when a provider payload lacks a required field or has the wrong shape
+func UnexpectedResponse(raw map[string]interface{}, message string) error {
+	return &Error{
+		Message: "unexpected response: " + message,
+		Status:  http.StatusBadGateway,
+		Code:    "unexpected_response",
+		Raw:     raw,
+	}
+}
@@ -0,0 +1,78 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{"same sentinel", ErrTokenExpired, ErrTokenExpired, true},
+		{"expired_token alias", &Error{Code: "expired_token", Status: 400}, ErrTokenExpired, true},
+		{"invalid_token alias", &Error{Code: "invalid_token", Status: 401}, ErrTokenInvalid, true},
+		{"kind code", &Error{Code: "rate_limited", NativeCode: "4"}, ErrRateLimited, true},
+		{"consent_revoked", &Error{Code: "consent_revoked"}, ErrRevoked, true},
+		{"access_denied", &Error{Code: "access_denied", Status: 400}, ErrDenied, true},
+		{"unexpected_response", UnexpectedResponse(nil, "id"), ErrUnexpectedResponse, true},
+		{"rfc code target", &Error{Code: "invalid_grant"}, &Error{Code: "invalid_grant"}, true},
+		{"wrapped", fmt.Errorf("refresh: %w", &Error{Code: "expired_token"}), ErrTokenExpired, true},
+		{"other code", &Error{Code: "invalid_grant"}, ErrTokenExpired, false},
+		{"expired is not invalid", ErrTokenExpired, ErrTokenInvalid, false},
+		{"denied is not cancel", ErrDenied, ErrCancel, false},
+		{"empty code", NewError("boom", 500), ErrUnexpectedResponse, false},
+		{"empty target code", &Error{Code: "invalid_grant"}, &Error{}, false},
+		{"status alone", &Error{Status: 429}, ErrRateLimited, false},
+		{"other error type", &Error{Code: "token_expired"}, errors.New("token_expired"), false},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if got := errors.Is(test.err, test.target); got != test.want {
+				t.Fatalf("errors.Is(%v, %v) = %v, want %v", test.err, test.target, got, test.want)
+			}
+		})
+	}
+}
+
+func TestErrorRetryable(t *testing.T) {
+	header := http.Header{"Content-Type": {"application/json"}}
+	tests := []struct {
+		name string
+		err  *Error
+		want bool
+	}{
+		{"400", &Error{Status: 400, Header: header}, false},
+		{"401", &Error{Status: 401, Header: header}, false},
+		{"403", &Error{Status: 403, Header: header}, false},
+		{"404", &Error{Status: 404, Header: header}, false},
+		{"invalid_grant", &Error{Status: 400, Code: "invalid_grant", Header: header}, false},
+		{"token_expired", &Error{Status: 401, Code: "token_expired", Header: header}, false},
+		{"429", &Error{Status: 429, Header: header}, true},
+		{"429 without header", &Error{Status: 429}, true},
+		{"rate_limited kind on 200", &Error{Status: 200, Code: "rate_limited", NativeCode: "4"}, true},
+		{"slow_down", &Error{Status: 400, Code: "slow_down"}, true},
+		{"temporarily_unavailable", &Error{Status: 400, Code: "temporarily_unavailable"}, true},
+		{"server_error", &Error{Status: 400, Code: "server_error"}, true},
+		{"500 of the provider", &Error{Status: 500, Header: header}, true},
+		{"500 local", &Error{Status: 500}, false},
+		{"502", &Error{Status: 502}, true},
+		{"503", &Error{Status: 503}, true},
+		{"504", &Error{Status: 504}, true},
+		{"unexpected_response", UnexpectedResponse(nil, "id").(*Error), false},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.err.Retryable(); got != test.want {
+				t.Fatalf("Retryable() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/url"
 	"os"
@@ -14,145 +13,35 @@ import (
 	"strings"
 
 	"github.com/otamoe/oauth-client"
-	"github.com/otamoe/oauth-client/amazon"
-	"github.com/otamoe/oauth-client/baidu"
-	"github.com/otamoe/oauth-client/bitbucket"
+	_ "github.com/otamoe/oauth-client/amazon"
+	_ "github.com/otamoe/oauth-client/baidu"
+	_ "github.com/otamoe/oauth-client/bitbucket"
+	"github.com/otamoe/oauth-client/config"
 	"github.com/otamoe/oauth-client/facebook"
-	"github.com/otamoe/oauth-client/github"
-	"github.com/otamoe/oauth-client/gitlab"
-	"github.com/otamoe/oauth-client/google"
-	"github.com/otamoe/oauth-client/line"
-	"github.com/otamoe/oauth-client/linkedin"
-	"github.com/otamoe/oauth-client/microsoft"
-	"github.com/otamoe/oauth-client/qq"
-	"github.com/otamoe/oauth-client/twitch"
-	"github.com/otamoe/oauth-client/twitter"
-	"github.com/otamoe/oauth-client/wechat"
-	"github.com/otamoe/oauth-client/weibo"
+	_ "github.com/otamoe/oauth-client/github"
+	_ "github.com/otamoe/oauth-client/gitlab"
+	_ "github.com/otamoe/oauth-client/google"
+	_ "github.com/otamoe/oauth-client/line"
+	_ "github.com/otamoe/oauth-client/linkedin"
+	_ "github.com/otamoe/oauth-client/microsoft"
+	_ "github.com/otamoe/oauth-client/qq"
+	_ "github.com/otamoe/oauth-client/twitch"
+	_ "github.com/otamoe/oauth-client/twitter"
+	_ "github.com/otamoe/oauth-client/wechat"
+	_ "github.com/otamoe/oauth-client/weibo"
 )
 
 func Client(name string) (client oauth.Client) {
-	configb, err := ioutil.ReadFile("./config.json")
+	entries, err := config.Load("./config.json")
 	if err != nil {
 		log.Panicln(err)
 	}
-	configs := make(map[string]oauth.Config, 0)
-	if err := json.Unmarshal(configb, &configs); err != nil {
-		log.Panicln(err)
-		return
-	}
-	config, ok := configs[name]
+	entry, ok := entries[name]
 	if !ok {
 		log.Panicf("oauth %s does not exist", name)
 	}
-	switch name {
-	case "google":
-		config.Endpoint = google.Endpoint
-		client = &google.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	case "facebook":
-		config.Endpoint = facebook.Endpoint
-		client = &facebook.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	case "microsoft":
-		config.Endpoint = microsoft.Endpoint
-		client = &microsoft.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	case "amazon":
-		config.Endpoint = amazon.Endpoint
-		client = &amazon.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	case "linkedin":
-		config.Endpoint = linkedin.Endpoint
-		client = &linkedin.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	case "twitter":
-		config.Endpoint = twitter.Endpoint
-		client = &twitter.Client{
-			OAuth1: oauth.OAuth1{
-				Config: config,
-			},
-		}
-	case "line":
-		config.Endpoint = line.Endpoint
-		client = &line.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	case "github":
-		config.Endpoint = github.Endpoint
-		client = &github.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	case "gitlab":
-		config.Endpoint = gitlab.Endpoint
-		client = &gitlab.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	case "bitbucket":
-		config.Endpoint = bitbucket.Endpoint
-		client = &bitbucket.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	case "twitch":
-		config.Endpoint = twitch.Endpoint
-		client = &twitch.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	case "weibo":
-		config.Endpoint = weibo.Endpoint
-		client = &weibo.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	case "baidu":
-		config.Endpoint = baidu.Endpoint
-		client = &baidu.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	case "qq":
-		config.Endpoint = qq.Endpoint
-		client = &qq.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	case "wechat":
-		config.Endpoint = wechat.Endpoint
-		client = &wechat.Client{
-			OAuth2: oauth.OAuth2{
-				Config: config,
-			},
-		}
-	default:
-		log.Panicf("oauth %s does not exist", name)
+	if client, err = entry.Client(); err != nil {
+		log.Panicln(err)
 	}
 	return
 }
@@ -184,6 +73,9 @@ func main() {
 
 	var token *oauth.Token
 
+	for _, provider := range oauth.Providers() {
+		fmt.Printf("%s\t%s\t%s\n", provider.Name, provider.Version, strings.Join(provider.Capabilities, ","))
+	}
 	fmt.Println("Please enter name:")
 	if name, err = inputReader.ReadString('\n'); err != nil {
 		log.Fatalln(err)
@@ -0,0 +1,76 @@
+package oauth
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// Token type identifiers (RFC 8693)
+const (
+	TokenTypeAccessToken  = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeRefreshToken = "urn:ietf:params:oauth:token-type:refresh_token"
+	TokenTypeIDToken      = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeJWT          = "urn:ietf:params:oauth:token-type:jwt"
+	TokenTypeSAML2        = "urn:ietf:params:oauth:token-type:saml2"
+)
+
+type (
+	TokenExchange struct {
+		SubjectToken       string   `json:"subject_token"`
+		SubjectTokenType   string   `json:"subject_token_type"`
+		ActorToken         string   `json:"actor_token,omitempty"`
+		ActorTokenType     string   `json:"actor_token_type,omitempty"`
+		RequestedTokenType string   `json:"requested_token_type,omitempty"`
+		Audience           string   `json:"audience,omitempty"`
+		Resource           string   `json:"resource,omitempty"`
+		Scopes             []string `json:"scopes,omitempty"`
+	}
+)
+
+// ExchangeToken swaps a subject token for a new one (RFC 8693), issued_token_type is kept in Token.Raw
+func (c *OAuth2) ExchangeToken(ctx context.Context, exchange *TokenExchange, values url.Values) (token *Token, err error) {
+	if exchange.SubjectToken == "" {
+		err = NewError("TokenExchange.SubjectToken is empty", 500)
+		return
+	}
+	subjectTokenType := exchange.SubjectTokenType
+	if subjectTokenType == "" {
+		subjectTokenType = TokenTypeAccessToken
+	}
+	if exchange.ActorToken != "" && exchange.ActorTokenType == "" {
+		err = NewError("TokenExchange.ActorTokenType is empty", 500)
+		return
+	}
+
+	AppendValues := url.Values{
+		"grant_type":         {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"subject_token":      {exchange.SubjectToken},
+		"subject_token_type": {subjectTokenType},
+	}
+	// optional parameters are only sent when set, so the same parameters in values are kept
+	if exchange.ActorToken != "" {
+		AppendValues.Set("actor_token", exchange.ActorToken)
+		AppendValues.Set("actor_token_type", exchange.ActorTokenType)
+	}
+	if exchange.RequestedTokenType != "" {
+		AppendValues.Set("requested_token_type", exchange.RequestedTokenType)
+	}
+	if exchange.Audience != "" {
+		AppendValues.Set("audience", exchange.Audience)
+	}
+	if exchange.Resource != "" {
+		AppendValues.Set("resource", exchange.Resource)
+	}
+	if len(exchange.Scopes) > 0 {
+		sep := c.Endpoint.ScopeSep
+		if sep == "" {
+			sep = " "
+		}
+		AppendValues.Set("scope", strings.Join(exchange.Scopes, sep))
+	}
+
+	token = &Token{}
+	err = c.RequestToken(ctx, c.Endpoint.AccessTokenURL, token, values, AppendValues)
+	return
+}
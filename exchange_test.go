@@ -0,0 +1,92 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestExchangeToken(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"a2","token_type":"bearer","issued_token_type":"urn:ietf:params:oauth:token-type:access_token"}`))
+	}))
+	defer server.Close()
+	client := &OAuth2{Config: Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Endpoint:     Endpoint{AccessTokenURL: server.URL},
+	}}
+	base := url.Values{
+		"grant_type":         {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"subject_token":      {"a1"},
+		"subject_token_type": {TokenTypeAccessToken},
+		"client_id":          {"client"},
+		"client_secret":      {"secret"},
+	}
+	with := func(extra url.Values) url.Values {
+		values := url.Values{}
+		for key, val := range base {
+			values[key] = val
+		}
+		for key, val := range extra {
+			values[key] = val
+		}
+		return values
+	}
+
+	tests := []struct {
+		name     string
+		exchange TokenExchange
+		values   url.Values
+		want     url.Values
+	}{
+		{"subject only", TokenExchange{SubjectToken: "a1"}, nil, base},
+		{
+			"caller values are kept when the option is empty",
+			TokenExchange{SubjectToken: "a1"},
+			url.Values{"audience": {"api"}, "resource": {"https://api.example.com"}, "scope": {"read"}},
+			with(url.Values{"audience": {"api"}, "resource": {"https://api.example.com"}, "scope": {"read"}}),
+		},
+		{
+			"options win over caller values",
+			TokenExchange{SubjectToken: "a1", Audience: "api2", Resource: "https://api2.example.com", Scopes: []string{"read", "write"}},
+			url.Values{"audience": {"api"}, "resource": {"https://api.example.com"}, "scope": {"read"}},
+			with(url.Values{"audience": {"api2"}, "resource": {"https://api2.example.com"}, "scope": {"read write"}}),
+		},
+		{
+			"actor and requested type",
+			TokenExchange{SubjectToken: "a1", SubjectTokenType: TokenTypeIDToken, ActorToken: "act", ActorTokenType: TokenTypeJWT, RequestedTokenType: TokenTypeRefreshToken},
+			nil,
+			with(url.Values{"subject_token_type": {TokenTypeIDToken}, "actor_token": {"act"}, "actor_token_type": {TokenTypeJWT}, "requested_token_type": {TokenTypeRefreshToken}}),
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			token, err := client.ExchangeToken(context.Background(), &test.exchange, test.values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token.AccessToken != "a2" || token.Raw["issued_token_type"] != TokenTypeAccessToken {
+				t.Fatalf("ExchangeToken() = %+v", token)
+			}
+			if !reflect.DeepEqual(form, test.want) {
+				t.Fatalf("token request %v, want %v", form, test.want)
+			}
+		})
+	}
+
+	if _, err := client.ExchangeToken(context.Background(), &TokenExchange{}, nil); err == nil {
+		t.Fatal("ExchangeToken() without subject token succeeded")
+	}
+	if _, err := client.ExchangeToken(context.Background(), &TokenExchange{SubjectToken: "a1", ActorToken: "act"}, nil); err == nil {
+		t.Fatal("ExchangeToken() without actor token type succeeded")
+	}
+}
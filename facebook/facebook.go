@@ -14,6 +14,21 @@ type (
 	Client struct {
 		oauth.OAuth2
 	}
+
+	userResponse struct {
+		ID        oauth.ID `json:"id"`
+		Name      string   `json:"name"`
+		FirstName string   `json:"first_name"`
+		LastName  string   `json:"last_name"`
+		Birthday  string   `json:"birthday"`
+		Gender    string   `json:"gender"`
+		Email     string   `json:"email"`
+		Picture   struct {
+			Data struct {
+				URL string `json:"url"`
+			} `json:"data"`
+		} `json:"picture"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -24,6 +39,30 @@ var Endpoint = oauth.Endpoint{
 	APIURL:          "https://graph.facebook.com/v3.0",
 	ClientHeader:    "Basic",
 	TokenHeader:     "Bearer",
+	// https://developers.facebook.com/docs/graph-api/guides/error-handling
+	ErrorCodes: map[string]string{
+		"4":   "rate_limited",
+		"17":  "rate_limited",
+		"32":  "rate_limited",
+		"102": "token_invalid",
+		"190": "token_invalid",
+		"613": "rate_limited",
+	},
+}
+
+func init() {
+	oauth.Register("facebook", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
 }
 
 func (c *Client) FbExchangeToken(ctx context.Context, oldToken *oauth.Token, values url.Values) (newToken *oauth.Token, err error) {
@@ -66,13 +105,14 @@ func (c *Client) ClientCode(ctx context.Context, token *oauth.Token, values url.
 	}
 	var ok bool
 	if code, ok = raw["code"].(string); !ok || code == "" {
-		err = oauth.NewError("Raw.code is empty", 500)
+		err = oauth.UnexpectedResponse(raw, "code is missing or invalid")
 		return
 	}
 	return
 }
 
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
 	var req *http.Request
 	if req, err = http.NewRequest("GET", c.Endpoint.APIURL+"/me?fields=first_name,address,birthday,email,context,gender,id,last_name,name,name_format,short_name,link,location,languages,hometown,middle_name,picture.type(large).redirect(false)", nil); err != nil {
@@ -80,56 +120,40 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 	}
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
+		return
+	}
+	if res.ID == "" {
+		err = oauth.UnexpectedResponse(raw, "id is missing or invalid")
 		return
 	}
 
 	user = &oauth.User{
-		ID:      raw["id"].(string),
-		Raw:     raw,
-		Updated: &now,
+		ID:         res.ID.String(),
+		Name:       res.Name,
+		Nickname:   res.Name,
+		GivenName:  res.FirstName,
+		FamilyName: res.LastName,
+		Avatar:     res.Picture.Data.URL,
+		Raw:        raw,
+		Updated:    &now,
 	}
 
-	if v, ok := raw["last_name"].(string); ok {
-		user.FamilyName = v
-	}
-	if v, ok := raw["first_name"].(string); ok {
-		user.GivenName = v
-	}
-	if v, ok := raw["name"].(string); ok {
-		user.Nickname = v
-		user.Name = v
-	}
-	if v, ok := raw["name"].(string); ok {
-		user.Nickname = v
-		user.Name = v
-	}
-	if v, ok := raw["birthday"].(string); ok && v != "" {
-		if birthday, err := time.Parse("01/02/2006", v); err == nil {
+	if res.Birthday != "" {
+		if birthday, err := time.Parse("01/02/2006", res.Birthday); err == nil {
 			user.Birthday = &birthday
 		}
 	}
-	if v, ok := raw["picture"].(map[string]interface{}); ok {
-		if v, ok := v["data"].(map[string]interface{}); ok {
-			if v, ok := v["url"].(string); ok {
-				user.Avatar = v
-			}
-		}
-	}
-	if v, ok := raw["gender"].(string); ok {
-		if v == "male" || v == "female" {
-			user.Gender = v
-		}
+	if res.Gender == "male" || res.Gender == "female" {
+		user.Gender = res.Gender
 	}
 
-	if v, ok := raw["email"].(string); ok && v != "" {
-		if user.Auths == nil {
-			user.Auths = make([]*oauth.Auth, 0)
-		}
+	if res.Email != "" {
 		user.Auths = append(user.Auths, &oauth.Auth{
 			Type:     "email",
-			Value:    v,
+			Value:    res.Email,
 			Verified: true,
 		})
 	}
@@ -0,0 +1,35 @@
+package facebook
+
+import (
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token"}, []providertest.Case{
+		{Name: "missing id", Body: `{"name":"a","email":"a@example.com"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "string id", Body: `{"id":"10158012345678901","name":"a","birthday":"08/31/1990","gender":"female","email":"a@example.com","picture":{"data":{"url":"https://example.com/a.jpg"}}}`, ID: "10158012345678901", Check: func(t *testing.T, user *oauth.User) {
+			if user.Avatar != "https://example.com/a.jpg" || user.Birthday == nil || user.Gender != "female" || len(user.Auths) != 1 {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "number id", Body: `{"id":10158012345678901}`, ID: "10158012345678901"},
+		{Name: "float id", Body: `{"id":1.5}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"id":"1","name":5,"first_name":[],"birthday":19900831,"gender":{},"email":true,"picture":"https://example.com/a.jpg"}`, ID: "1", Check: func(t *testing.T, user *oauth.User) {
+			if user.Name != "" || user.Avatar != "" || user.Birthday != nil || user.Gender != "" || len(user.Auths) != 0 {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "picture data as a list", Body: `{"id":"1","picture":{"data":[{"url":"https://example.com/a.jpg"}]}}`, ID: "1"},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "expired token code with status 200", Body: `{"error":{"message":"Error validating access token: Session has expired","type":"OAuthException","code":190,"error_subcode":463}}`, Err: oauth.ErrTokenInvalid},
+		{Name: "rate limit code", Status: 400, Body: `{"error":{"message":"(#4) Application request limit reached","type":"OAuthException","code":4}}`, Err: oauth.ErrRateLimited},
+		{Name: "user rate limit code", Status: 400, Body: `{"error":{"message":"(#17) User request limit reached","type":"OAuthException","code":17}}`, Err: oauth.ErrRateLimited},
+		{Name: "page rate limit code", Status: 400, Body: `{"error":{"message":"(#32) Page request limit reached","type":"OAuthException","code":32}}`, Err: oauth.ErrRateLimited},
+		{Name: "call rate limit code", Status: 400, Body: `{"error":{"message":"(#613) Calls to this api have exceeded the rate limit.","type":"OAuthException","code":613}}`, Err: oauth.ErrRateLimited},
+		{Name: "error with status 200", Body: `{"error":"invalid_token","error_description":"Malformed access token"}`, Err: oauth.ErrTokenInvalid},
+	})
+}
@@ -0,0 +1,31 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token"}, []providertest.Case{
+		{Name: "missing id", Body: `{"login":"octocat"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "number id", Body: `{"id":1234567890123456789,"login":"octocat","created_at":"2011-01-25T18:44:36Z"}`, ID: "1234567890123456789", Check: func(t *testing.T, user *oauth.User) {
+			if user.Username != "octocat" || user.Created == nil {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "string id", Body: `{"id":"583231","login":"octocat"}`, ID: "583231"},
+		{Name: "float id", Body: `{"id":1.5,"login":"octocat"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "null id", Body: `{"id":null,"login":"octocat"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"id":1,"login":5,"name":[],"email":{},"avatar_url":true,"html_url":1,"created_at":1296068472}`, ID: "1", Check: func(t *testing.T, user *oauth.User) {
+			if user.Username != "" || user.Avatar != "" || user.Created != nil || len(user.Auths) != 0 {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "bad created_at", Body: `{"id":1,"created_at":"yesterday"}`, ID: "1"},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "error with status 200", Body: `{"error":"bad_verification_code","error_description":"The code passed is incorrect or expired."}`, Err: &oauth.Error{Code: "bad_verification_code"}},
+	})
+}
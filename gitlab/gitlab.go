@@ -3,7 +3,6 @@ package gitlab
 import (
 	"context"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/otamoe/oauth-client"
@@ -13,6 +12,16 @@ type (
 	Client struct {
 		oauth.OAuth2
 	}
+
+	userResponse struct {
+		ID        oauth.ID `json:"id"`
+		Username  string   `json:"username"`
+		Name      string   `json:"name"`
+		State     string   `json:"state"`
+		Email     string   `json:"email"`
+		AvatarURL string   `json:"avatar_url"`
+		CreatedAt string   `json:"created_at"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -23,9 +32,28 @@ var Endpoint = oauth.Endpoint{
 	APIURL:          "https://gitlab.com/api/v4",
 	ClientHeader:    "Basic",
 	TokenHeader:     "Bearer",
+	PKCE:            oauth.PKCES256,
+	Issuer:          "https://gitlab.com",
+	JWKSURL:         "https://gitlab.com/oauth/discovery/keys",
+}
+
+func init() {
+	oauth.Register("gitlab", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
 }
 
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
 	var req *http.Request
 	if req, err = http.NewRequest("GET", c.Endpoint.APIURL+"/user", nil); err != nil {
@@ -33,47 +61,39 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 	}
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
 		return
 	}
-
-	user = &oauth.User{
-		ID:      strconv.FormatFloat(raw["id"].(float64), 'f', 0, 64),
-		Raw:     raw,
-		Updated: &now,
+	if res.ID == "" {
+		err = oauth.UnexpectedResponse(raw, "id is missing or invalid")
+		return
 	}
 
-	if v, ok := raw["username"].(string); ok && v != "" {
-		user.Username = v
-		user.Link = "https://gitlab.com/" + v
+	user = &oauth.User{
+		ID:       res.ID.String(),
+		Username: res.Username,
+		Nickname: res.Name,
+		Avatar:   res.AvatarURL,
+		Raw:      raw,
+		Updated:  &now,
 	}
-	if v, ok := raw["avatar_url"].(string); ok {
-		user.Avatar = v
+	if res.Username != "" {
+		user.Link = "https://gitlab.com/" + res.Username
 	}
 
-	if v, ok := raw["name"].(string); ok {
-		user.Nickname = v
-	}
-
-	if v, ok := raw["created_at"].(string); ok && v != "" {
-		if created, err := time.Parse(time.RFC3339, v); err == nil {
+	if res.CreatedAt != "" {
+		if created, err := time.Parse(time.RFC3339, res.CreatedAt); err == nil {
 			user.Created = &created
 		}
 	}
 
-	if v, ok := raw["email"].(string); ok && v != "" {
-		var verified bool
-		if state, ok := raw["state"].(string); ok && state == "active" {
-			verified = true
-		}
-		if user.Auths == nil {
-			user.Auths = make([]*oauth.Auth, 0)
-		}
+	if res.Email != "" {
 		user.Auths = append(user.Auths, &oauth.Auth{
 			Type:     "email",
-			Value:    v,
-			Verified: verified,
+			Value:    res.Email,
+			Verified: res.State == "active",
 		})
 	}
 	return
@@ -0,0 +1,29 @@
+package gitlab
+
+import (
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token"}, []providertest.Case{
+		{Name: "missing id", Body: `{"username":"jdoe"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "number id", Body: `{"id":1234567890123456789,"username":"jdoe","state":"active","email":"j@example.com"}`, ID: "1234567890123456789", Check: func(t *testing.T, user *oauth.User) {
+			if user.Link != "https://gitlab.com/jdoe" || len(user.Auths) != 1 || !user.Auths[0].Verified {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "string id", Body: `{"id":"42"}`, ID: "42"},
+		{Name: "float id", Body: `{"id":4.2e1}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"id":1,"username":["jdoe"],"name":5,"state":true,"email":{},"avatar_url":1,"created_at":false}`, ID: "1", Check: func(t *testing.T, user *oauth.User) {
+			if user.Username != "" || user.Link != "" || user.Created != nil || len(user.Auths) != 0 {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "error with status 200", Body: `{"error":"invalid_token","error_description":"Token is expired."}`, Err: oauth.ErrTokenInvalid},
+	})
+}
@@ -1,3 +1,5 @@
 module github.com/otamoe/oauth-client
 
+go 1.21
+
 require golang.org/x/net v0.0.0-20190404232315-eb5bcb51f2a3
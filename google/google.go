@@ -12,6 +12,19 @@ type (
 	Client struct {
 		oauth.OAuth2
 	}
+
+	userResponse struct {
+		ID            oauth.ID `json:"id"`
+		Name          string   `json:"name"`
+		GivenName     string   `json:"given_name"`
+		FamilyName    string   `json:"family_name"`
+		Picture       string   `json:"picture"`
+		Locale        string   `json:"locale"`
+		Gender        string   `json:"gender"`
+		Link          string   `json:"link"`
+		Email         string   `json:"email"`
+		VerifiedEmail bool     `json:"verified_email"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -21,11 +34,31 @@ var Endpoint = oauth.Endpoint{
 	RefreshTokenURL: "https://accounts.google.com/o/oauth2/token",
 	RevokeTokenURL:  "https://accounts.google.com/o/oauth2/revoke",
 	APIURL:          "https://www.googleapis.com",
+	DeviceURL:       "https://oauth2.googleapis.com/device/code",
 	ClientHeader:    "Basic",
 	TokenHeader:     "Bearer",
+	PKCE:            oauth.PKCES256,
+	Issuer:          "https://accounts.google.com",
+	JWKSURL:         "https://www.googleapis.com/oauth2/v3/certs",
+}
+
+func init() {
+	oauth.Register("google", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
 }
 
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
 	var req *http.Request
 	// if req, err = http.NewRequest("GET", c.Endpoint.APIURL+"/userinfo/v2/me", nil); err != nil {
@@ -34,48 +67,34 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 	}
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
 		return
 	}
-
-	user = &oauth.User{
-		ID:      raw["id"].(string),
-		Raw:     raw,
-		Updated: &now,
+	if res.ID == "" {
+		err = oauth.UnexpectedResponse(raw, "id is missing or invalid")
+		return
 	}
 
-	if v, ok := raw["family_name"].(string); ok {
-		user.FamilyName = v
-	}
-	if v, ok := raw["given_name"].(string); ok {
-		user.GivenName = v
-	}
-	if v, ok := raw["name"].(string); ok {
-		user.Name = v
-	}
-	if v, ok := raw["picture"].(string); ok {
-		user.Avatar = v
-	}
-	if v, ok := raw["locale"].(string); ok {
-		user.Locale = oauth.FormatLocale(v)
-	}
-	if v, ok := raw["gender"].(string); ok {
-		user.Gender = v
-	}
-	if v, ok := raw["link"].(string); ok {
-		user.Link = v
+	user = &oauth.User{
+		ID:         res.ID.String(),
+		Name:       res.Name,
+		GivenName:  res.GivenName,
+		FamilyName: res.FamilyName,
+		Avatar:     res.Picture,
+		Locale:     oauth.FormatLocale(res.Locale),
+		Gender:     res.Gender,
+		Link:       res.Link,
+		Raw:        raw,
+		Updated:    &now,
 	}
 
-	if v, ok := raw["email"].(string); ok && v != "" {
-		verified, _ := raw["verified_email"].(bool)
-		if user.Auths == nil {
-			user.Auths = make([]*oauth.Auth, 0)
-		}
+	if res.Email != "" {
 		user.Auths = append(user.Auths, &oauth.Auth{
 			Type:     "email",
-			Value:    v,
-			Verified: verified,
+			Value:    res.Email,
+			Verified: res.VerifiedEmail,
 		})
 	}
 
@@ -0,0 +1,39 @@
+package google
+
+import (
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	locale := func(want string) func(t *testing.T, user *oauth.User) {
+		return func(t *testing.T, user *oauth.User) {
+			if user.Locale != want {
+				t.Fatalf("User().Locale = %q, want %q", user.Locale, want)
+			}
+		}
+	}
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token"}, []providertest.Case{
+		{Name: "missing id", Body: `{"email":"a@example.com","verified_email":true}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "string id", Body: `{"id":"108012345678901234567","email":"a@example.com","verified_email":true,"locale":"en_us"}`, ID: "108012345678901234567", Check: func(t *testing.T, user *oauth.User) {
+			if user.Locale != "en-US" || len(user.Auths) != 1 || !user.Auths[0].Verified {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "number id", Body: `{"id":108012345678901234567}`, ID: "108012345678901234567"},
+		{Name: "float id", Body: `{"id":1.08e20}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"id":"1","name":5,"given_name":[],"picture":{},"locale":7,"gender":true,"email":"a@example.com","verified_email":"true"}`, ID: "1", Check: func(t *testing.T, user *oauth.User) {
+			if user.Name != "" || user.Locale != "" || len(user.Auths) != 1 || user.Auths[0].Verified {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "locale with leading separator", Body: `{"id":"1","locale":"-us-x"}`, ID: "1", Check: locale("us-X")},
+		{Name: "locale with underscores", Body: `{"id":"1","locale":"_a_b"}`, ID: "1", Check: locale("a-B")},
+		{Name: "locale with empty first segment", Body: `{"id":"1","locale":"-a-b-c"}`, ID: "1", Check: locale("a-B-C")},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "error with status 200", Body: `{"error":"invalid_token","error_description":"Invalid Credentials"}`, Err: oauth.ErrTokenInvalid},
+	})
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/otamoe/oauth-client"
+)
+
+type (
+	// CookieStateStore keeps Authorize data in the browser, sealed with Sealer and signed with HashKey.
+	// Consumed states are remembered in memory until they expire, so with several instances a replayed cookie is only rejected by the instance that saw it
+	CookieStateStore struct {
+		Sealer  *oauth.Sealer
+		HashKey []byte
+		// Name prefixes the cookie name, one cookie is set per pending state
+		Name     string
+		Path     string
+		Domain   string
+		Secure   bool
+		SameSite http.SameSite
+		TTL      time.Duration
+
+		mu       sync.Mutex
+		consumed map[string]time.Time
+	}
+
+	cookieState struct {
+		ID       string                 `json:"id"`
+		Provider string                 `json:"provider"`
+		State    string                 `json:"state"`
+		Data     map[string]interface{} `json:"data"`
+		Expired  int64                  `json:"expired"`
+	}
+)
+
+func NewCookieStateStore(hashKey []byte, sealer *oauth.Sealer) *CookieStateStore {
+	return &CookieStateStore{
+		Sealer:  sealer,
+		HashKey: hashKey,
+		Secure:  true,
+	}
+}
+
+func (s *CookieStateStore) cookieName(state string) string {
+	name := s.Name
+	if name == "" {
+		name = "oauth_state"
+	}
+	return stateCookieName(name, state)
+}
+
+func stateCookieName(prefix string, state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return prefix + "_" + hex.EncodeToString(sum[:8])
+}
+
+func (s *CookieStateStore) cookie(name string, value string, maxAge int) *http.Cookie {
+	path := s.Path
+	if path == "" {
+		path = "/"
+	}
+	// the callback is a cross site top level navigation, Strict would drop the cookie
+	sameSite := s.SameSite
+	if sameSite == 0 {
+		sameSite = http.SameSiteLaxMode
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   s.Domain,
+		MaxAge:   maxAge,
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: sameSite,
+	}
+}
+
+func (s *CookieStateStore) sign(name string, sealed string) string {
+	mac := hmac.New(sha256.New, s.HashKey)
+	mac.Write([]byte(name + "\n" + sealed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *CookieStateStore) Save(w http.ResponseWriter, r *http.Request, provider string, state string, data map[string]interface{}) (err error) {
+	if s.Sealer == nil || len(s.HashKey) == 0 {
+		err = oauth.NewError("CookieStateStore: Sealer or HashKey is empty", 500)
+		return
+	}
+	ttl := s.TTL
+	if ttl == 0 {
+		ttl = StateTTL
+	}
+	var b []byte
+	if b, err = json.Marshal(&cookieState{
+		ID:       oauth.RandString(16),
+		Provider: provider,
+		State:    state,
+		Data:     data,
+		Expired:  time.Now().Add(ttl).Unix(),
+	}); err != nil {
+		return
+	}
+	var sealed string
+	if sealed, err = s.Sealer.Seal(b); err != nil {
+		return
+	}
+	name := s.cookieName(state)
+	http.SetCookie(w, s.cookie(name, sealed+"."+s.sign(name, sealed), int(ttl/time.Second)))
+	return
+}
+
+func (s *CookieStateStore) Load(w http.ResponseWriter, r *http.Request, provider string, state string) (data map[string]interface{}, err error) {
+	if s.Sealer == nil || len(s.HashKey) == 0 {
+		err = oauth.NewError("CookieStateStore: Sealer or HashKey is empty", 500)
+		return
+	}
+	name := s.cookieName(state)
+	cookie, e := r.Cookie(name)
+	if e != nil {
+		err = ErrStateInvalid
+		return
+	}
+	http.SetCookie(w, s.cookie(name, "", -1))
+
+	i := strings.LastIndex(cookie.Value, ".")
+	if i == -1 {
+		err = ErrStateInvalid
+		return
+	}
+	sealed := cookie.Value[:i]
+	if !hmac.Equal([]byte(cookie.Value[i+1:]), []byte(s.sign(name, sealed))) {
+		err = ErrStateInvalid
+		return
+	}
+	var b []byte
+	if b, err = s.Sealer.Open(sealed); err != nil {
+		err = ErrStateInvalid
+		return
+	}
+	payload := &cookieState{}
+	if err = json.Unmarshal(b, payload); err != nil {
+		err = ErrStateInvalid
+		return
+	}
+
+	now := time.Now()
+	expired := time.Unix(payload.Expired, 0)
+	if payload.Provider != provider || !hmac.Equal([]byte(payload.State), []byte(state)) || now.After(expired) {
+		err = ErrStateInvalid
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.consumed == nil {
+		s.consumed = map[string]time.Time{}
+	}
+	for id, t := range s.consumed {
+		if now.After(t) {
+			delete(s.consumed, id)
+		}
+	}
+	if _, ok := s.consumed[payload.ID]; ok {
+		err = ErrStateInvalid
+		return
+	}
+	s.consumed[payload.ID] = expired
+
+	data = payload.Data
+	return
+}
@@ -0,0 +1,148 @@
+// Package handler provides net/http handlers for the login and callback steps of the authorization flow.
+//
+//	h := handler.New(clients, func(w http.ResponseWriter, r *http.Request, provider string, token *oauth.Token, user *oauth.User) {
+//		// create the session
+//	})
+//	mux.Handle("/login/", h.Login())
+//	mux.Handle("/callback/", h.Callback())
+package handler
+
+import (
+	"context"
+	"net/http"
+	"path"
+
+	"github.com/otamoe/oauth-client"
+)
+
+type (
+	SuccessFunc func(w http.ResponseWriter, r *http.Request, provider string, token *oauth.Token, user *oauth.User)
+
+	ErrorFunc func(w http.ResponseWriter, r *http.Request, provider string, err error)
+
+	Handler struct {
+		// Clients maps the provider path segment to its client
+		Clients map[string]oauth.Client
+		// Store defaults to a MemoryStateStore
+		Store   StateStore
+		Success SuccessFunc
+		// Error defaults to http.Error with the status of *oauth.Error
+		Error ErrorFunc
+	}
+
+	idTokenVerifier interface {
+		CanVerifyIDToken() bool
+		VerifyIDToken(ctx context.Context, token *oauth.Token, data map[string]interface{}) (claims *oauth.IDTokenClaims, err error)
+	}
+)
+
+var ErrStateInvalid = oauth.NewError("state_invalid", 403)
+
+var ErrProviderNotFound = oauth.NewError("provider_not_found", 404)
+
+var defaultStore = NewMemoryStateStore(0)
+
+func New(clients map[string]oauth.Client, success SuccessFunc) *Handler {
+	return &Handler{
+		Clients: clients,
+		Store:   NewMemoryStateStore(0),
+		Success: success,
+	}
+}
+
+// Login redirects to the authorization url of the provider named by the last path segment
+func (h *Handler) Login() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provider := path.Base(r.URL.Path)
+		client, ok := h.Clients[provider]
+		if !ok {
+			h.error(w, r, provider, ErrProviderNotFound)
+			return
+		}
+
+		state := oauth.RandString(32)
+		authorizeURL, data, err := client.Authorize(r.Context(), state, nil)
+		if err != nil {
+			h.error(w, r, provider, err)
+			return
+		}
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		if err = h.store().Save(w, r, provider, state, data); err != nil {
+			h.error(w, r, provider, err)
+			return
+		}
+		http.Redirect(w, r, authorizeURL.String(), http.StatusFound)
+	})
+}
+
+// Callback validates state, exchanges the code, verifies the id_token when the endpoint has an issuer and a key source and passes the token and user to Success
+func (h *Handler) Callback() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provider := path.Base(r.URL.Path)
+		client, ok := h.Clients[provider]
+		if !ok {
+			h.error(w, r, provider, ErrProviderNotFound)
+			return
+		}
+
+		ctx := r.Context()
+		query := r.URL.Query()
+		state := query.Get("state")
+		if state == "" {
+			h.error(w, r, provider, ErrStateInvalid)
+			return
+		}
+		data, err := h.store().Load(w, r, provider, state)
+		if err != nil {
+			h.error(w, r, provider, err)
+			return
+		}
+		if client.Cancel(query) {
+			h.error(w, r, provider, oauth.ErrCancel)
+			return
+		}
+
+		var token *oauth.Token
+		if token, err = client.Exchange(ctx, query, data, nil); err != nil {
+			h.error(w, r, provider, err)
+			return
+		}
+		// an id_token of a provider without issuer and JWKS url, such as twitch with the openid scope, is left to User
+		if verifier, ok := client.(idTokenVerifier); ok && token.IDToken != "" && verifier.CanVerifyIDToken() {
+			if _, err = verifier.VerifyIDToken(ctx, token, data); err != nil {
+				h.error(w, r, provider, err)
+				return
+			}
+		}
+
+		var user *oauth.User
+		if user, err = client.User(ctx, token); err != nil {
+			h.error(w, r, provider, err)
+			return
+		}
+		if h.Success != nil {
+			h.Success(w, r, provider, token, user)
+		}
+	})
+}
+
+func (h *Handler) store() StateStore {
+	if h.Store == nil {
+		return defaultStore
+	}
+	return h.Store
+}
+
+func (h *Handler) error(w http.ResponseWriter, r *http.Request, provider string, err error) {
+	if h.Error != nil {
+		h.Error(w, r, provider, err)
+		return
+	}
+	status := http.StatusInternalServerError
+	if e, ok := err.(*oauth.Error); ok && e.Status != 0 {
+		status = e.Status
+	}
+	http.Error(w, err.Error(), status)
+}
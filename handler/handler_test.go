@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/google"
+	"github.com/otamoe/oauth-client/twitch"
+)
+
+func TestCallbackIDToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/token":
+			w.Write([]byte(`{"access_token":"token","token_type":"bearer","id_token":"not.a.jwt"}`))
+		case "/users":
+			w.Write([]byte(`{"data":[{"id":"1"}]}`))
+		case "/oauth2/v2/userinfo":
+			w.Write([]byte(`{"id":"1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	endpoint := oauth.Endpoint{AccessTokenURL: server.URL + "/token", APIURL: server.URL}
+	config := oauth.Config{ClientID: "client", ClientSecret: "secret", Scopes: []string{"openid"}, Endpoint: endpoint}
+	tests := []struct {
+		name   string
+		client oauth.Client
+		status int
+	}{
+		// twitch has no issuer or JWKS url, the id_token is not verified
+		{"no key source", twitch.New(config), http.StatusOK},
+		{"jwks url", google.New(config), http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var called bool
+			h := New(map[string]oauth.Client{"provider": test.client}, func(w http.ResponseWriter, r *http.Request, provider string, token *oauth.Token, user *oauth.User) {
+				called = true
+			})
+			w := httptest.NewRecorder()
+			h.Login().ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/login/provider", nil))
+			location, err := w.Result().Location()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := httptest.NewRequest("GET", "http://example.com/callback/provider?code=code&state="+location.Query().Get("state"), nil)
+			for _, cookie := range w.Result().Cookies() {
+				r.AddCookie(cookie)
+			}
+			w = httptest.NewRecorder()
+			h.Callback().ServeHTTP(w, r)
+			if w.Code != test.status || called != (test.status == http.StatusOK) {
+				t.Fatalf("Callback() status %d, success called %v: %s", w.Code, called, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/otamoe/oauth-client"
+)
+
+type (
+	// StateStore keeps the data returned by Authorize until the callback, Load must remove it so a state is used once
+	StateStore interface {
+		Save(w http.ResponseWriter, r *http.Request, provider string, state string, data map[string]interface{}) (err error)
+		Load(w http.ResponseWriter, r *http.Request, provider string, state string) (data map[string]interface{}, err error)
+	}
+
+	// MemoryStateStore keeps Authorize data server side. A random cookie binds each state to the browser that started the login
+	MemoryStateStore struct {
+		TTL time.Duration
+		// Cookie prefixes the binding cookie name
+		Cookie string
+		// Secure is set by NewMemoryStateStore, clear it only to test over plain http since TLS usually ends at a proxy
+		Secure  bool
+		mu      sync.Mutex
+		entries map[string]*stateEntry
+	}
+
+	stateEntry struct {
+		data    map[string]interface{}
+		binding string
+		expired time.Time
+	}
+)
+
+// StateTTL is how long a login may take before its state expires
+var StateTTL = 10 * time.Minute
+
+func NewMemoryStateStore(ttl time.Duration) *MemoryStateStore {
+	return &MemoryStateStore{
+		TTL:    ttl,
+		Secure: true,
+	}
+}
+
+func (s *MemoryStateStore) cookieName(state string) string {
+	prefix := s.Cookie
+	if prefix == "" {
+		prefix = "oauth_bind"
+	}
+	return stateCookieName(prefix, state)
+}
+
+func (s *MemoryStateStore) Save(w http.ResponseWriter, r *http.Request, provider string, state string, data map[string]interface{}) (err error) {
+	ttl := s.TTL
+	if ttl == 0 {
+		ttl = StateTTL
+	}
+	now := time.Now()
+	binding := oauth.RandString(32)
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.cookieName(state),
+		Value:    binding,
+		Path:     "/",
+		MaxAge:   int(ttl / time.Second),
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = map[string]*stateEntry{}
+	}
+	for key, entry := range s.entries {
+		if now.After(entry.expired) {
+			delete(s.entries, key)
+		}
+	}
+	s.entries[provider+"\n"+state] = &stateEntry{
+		data:    data,
+		binding: binding,
+		expired: now.Add(ttl),
+	}
+	return
+}
+
+func (s *MemoryStateStore) Load(w http.ResponseWriter, r *http.Request, provider string, state string) (data map[string]interface{}, err error) {
+	name := s.cookieName(state)
+	cookie, e := r.Cookie(name)
+	if e != nil {
+		err = ErrStateInvalid
+		return
+	}
+
+	key := provider + "\n" + state
+	s.mu.Lock()
+	entry, ok := s.entries[key]
+	// a request without the binding cookie must not consume the state
+	if ok && subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(entry.binding)) != 1 {
+		ok = false
+	} else {
+		delete(s.entries, key)
+	}
+	s.mu.Unlock()
+	if !ok || time.Now().After(entry.expired) {
+		err = ErrStateInvalid
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	data = entry.data
+	return
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otamoe/oauth-client"
+)
+
+func TestMemoryStateStoreCookie(t *testing.T) {
+	store := NewMemoryStateStore(0)
+	// TLS ends at a proxy, the request reaching the store is plain http
+	r := httptest.NewRequest("GET", "http://example.com/login/github", nil)
+	w := httptest.NewRecorder()
+	if err := store.Save(w, r, "github", "state", map[string]interface{}{"a": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("got %d cookies, want 1", len(cookies))
+	}
+	cookie := cookies[0]
+	if !cookie.Secure || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("cookie %s is not Secure, HttpOnly and SameSite=Lax", cookie)
+	}
+
+	r = httptest.NewRequest("GET", "http://example.com/callback/github", nil)
+	r.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	w = httptest.NewRecorder()
+	data, err := store.Load(w, r, "github", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["a"] != "b" {
+		t.Fatalf("Load() = %v", data)
+	}
+	if _, err = store.Load(httptest.NewRecorder(), r, "github", "state"); err != ErrStateInvalid {
+		t.Fatalf("second Load() error = %v, want ErrStateInvalid", err)
+	}
+
+	store.Secure = false
+	w = httptest.NewRecorder()
+	if err = store.Save(w, r, "github", "other", nil); err != nil {
+		t.Fatal(err)
+	}
+	if w.Result().Cookies()[0].Secure {
+		t.Fatal("cookie is Secure with Secure = false")
+	}
+}
+
+func TestCookieStateStore(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	store := NewCookieStateStore([]byte("hash key"), oauth.NewSealer("k1", key))
+
+	save := func(provider string, state string) *http.Cookie {
+		t.Helper()
+		w := httptest.NewRecorder()
+		if err := store.Save(w, httptest.NewRequest("GET", "http://example.com/login/"+provider, nil), provider, state, map[string]interface{}{"code_verifier": "v"}); err != nil {
+			t.Fatal(err)
+		}
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 {
+			t.Fatalf("got %d cookies, want 1", len(cookies))
+		}
+		return cookies[0]
+	}
+	load := func(cookie *http.Cookie, provider string, state string) (map[string]interface{}, error) {
+		r := httptest.NewRequest("GET", "http://example.com/callback/"+provider, nil)
+		r.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+		return store.Load(httptest.NewRecorder(), r, provider, state)
+	}
+	// signed returns a cookie for state whose HMAC is valid
+	signed := func(state string, sealed string) *http.Cookie {
+		name := store.cookieName(state)
+		return &http.Cookie{Name: name, Value: sealed + "." + store.sign(name, sealed)}
+	}
+	sealPayload := func(sealer *oauth.Sealer, payload *cookieState) string {
+		t.Helper()
+		b, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sealed, err := sealer.Seal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sealed
+	}
+
+	cookie := save("github", "s1")
+	if !cookie.Secure || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("cookie %s is not Secure, HttpOnly and SameSite=Lax", cookie)
+	}
+	data, err := load(cookie, "github", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["code_verifier"] != "v" {
+		t.Fatalf("Load() = %v", data)
+	}
+	if _, err = load(cookie, "github", "s1"); err != ErrStateInvalid {
+		t.Fatalf("replayed Load() error = %v, want ErrStateInvalid", err)
+	}
+
+	cookie = save("github", "s2")
+	tampered := *cookie
+	i := strings.LastIndex(tampered.Value, ".")
+	tampered.Value = tampered.Value[:i+1] + store.sign("other", tampered.Value[:i])
+	if _, err = load(&tampered, "github", "s2"); err != ErrStateInvalid {
+		t.Fatalf("Load() with a bad HMAC error = %v, want ErrStateInvalid", err)
+	}
+	if _, err = load(cookie, "google", "s2"); err != ErrStateInvalid {
+		t.Fatalf("Load() for another provider error = %v, want ErrStateInvalid", err)
+	}
+
+	// a valid HMAC over an envelope the Sealer cannot open
+	other := oauth.NewSealer("k1", bytes.Repeat([]byte{2}, 32))
+	forged := signed("s3", sealPayload(other, &cookieState{ID: "x", Provider: "github", State: "s3", Expired: time.Now().Add(time.Minute).Unix()}))
+	if _, err = load(forged, "github", "s3"); err != ErrStateInvalid {
+		t.Fatalf("Load() of a foreign envelope error = %v, want ErrStateInvalid", err)
+	}
+
+	expired := signed("s4", sealPayload(store.Sealer, &cookieState{ID: "y", Provider: "github", State: "s4", Expired: time.Now().Add(-time.Second).Unix()}))
+	if _, err = load(expired, "github", "s4"); err != ErrStateInvalid {
+		t.Fatalf("Load() of an expired state error = %v, want ErrStateInvalid", err)
+	}
+
+	// a cookie moved to the name of another state
+	value := save("github", "s5").Value
+	moved := signed("s6", value[:strings.LastIndex(value, ".")])
+	if _, err = load(moved, "github", "s6"); err != ErrStateInvalid {
+		t.Fatalf("Load() with another state error = %v, want ErrStateInvalid", err)
+	}
+
+	if err = (&CookieStateStore{}).Save(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com/", nil), "github", "s", nil); err == nil {
+		t.Fatal("Save() without Sealer and HashKey succeeded")
+	}
+}
@@ -0,0 +1,59 @@
+package oauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// ID is a user id sent as a JSON string or integer, integers keep every digit instead of passing through float64.
+// Any other value, such as a fraction, an object or null, leaves the ID empty for the caller to reject
+type ID string
+
+func (id *ID) UnmarshalJSON(b []byte) (err error) {
+	b = bytes.TrimSpace(b)
+	*id = ""
+	switch {
+	case len(b) != 0 && b[0] == '"':
+		var s string
+		if json.Unmarshal(b, &s) == nil {
+			*id = ID(s)
+		}
+	case isInteger(b):
+		*id = ID(b)
+	}
+	return
+}
+
+func isInteger(b []byte) bool {
+	if len(b) != 0 && b[0] == '-' {
+		b = b[1:]
+	}
+	if len(b) == 0 {
+		return false
+	}
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (id ID) String() string {
+	return string(id)
+}
+
+// RawID returns the exact string form of an id taken from a Raw map
+func RawID(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		// Raw decoded without json.Number, such as a token loaded from a store
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
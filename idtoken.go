@@ -0,0 +1,274 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type (
+	IDTokenClaims struct {
+		Issuer          string                 `json:"iss"`
+		Subject         string                 `json:"sub"`
+		Audience        []string               `json:"aud"`
+		AuthorizedParty string                 `json:"azp,omitempty"`
+		Nonce           string                 `json:"nonce,omitempty"`
+		Email           string                 `json:"email,omitempty"`
+		EmailVerified   bool                   `json:"email_verified,omitempty"`
+		Name            string                 `json:"name,omitempty"`
+		GivenName       string                 `json:"given_name,omitempty"`
+		FamilyName      string                 `json:"family_name,omitempty"`
+		Picture         string                 `json:"picture,omitempty"`
+		Locale          string                 `json:"locale,omitempty"`
+		Raw             map[string]interface{} `json:"raw,omitempty"`
+		Expired         *time.Time             `json:"expired"`
+		Issued          *time.Time             `json:"issued,omitempty"`
+		AuthTime        *time.Time             `json:"auth_time,omitempty"`
+	}
+
+	IDTokenVerifier struct {
+		// Issuer may contain {tenantid}, replaced by the tid claim (microsoft common endpoint)
+		Issuer       string
+		ClientID     string
+		ClientSecret string
+		JWKSURL      string
+		Leeway       time.Duration
+		Algorithms   []string
+		// Client fetches the JWKS with its Retry, Log and Instrument, nil uses the default client
+		Client Client
+	}
+)
+
+var IDTokenLeeway = time.Minute
+
+var IDTokenAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+func (v *IDTokenVerifier) Verify(ctx context.Context, rawIDToken string, nonce string) (claims *IDTokenClaims, err error) {
+	if rawIDToken == "" {
+		err = NewError("id_token: is empty", 401)
+		return
+	}
+	var jwt *JWT
+	if jwt, err = ParseJWT(rawIDToken); err != nil {
+		return
+	}
+
+	// HS256 is verified with the client secret, it is only accepted when listed in Algorithms
+	algorithms := v.Algorithms
+	if algorithms == nil {
+		algorithms = IDTokenAlgorithms
+	}
+	var allowed bool
+	for _, alg := range algorithms {
+		if alg == jwt.Header.Alg {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		err = NewError("id_token: alg not allowed: "+jwt.Header.Alg, 401)
+		return
+	}
+
+	if strings.HasPrefix(jwt.Header.Alg, "HS") {
+		if v.ClientSecret == "" {
+			err = NewError("id_token: client secret is empty", 500)
+			return
+		}
+		err = jwt.Verify([]byte(v.ClientSecret))
+	} else {
+		err = v.verifyJWKS(ctx, jwt)
+	}
+	if err != nil {
+		return
+	}
+
+	if v.Issuer == "" {
+		err = NewError("id_token: issuer is empty", 500)
+		return
+	}
+	claims = parseIDTokenClaims(jwt.Claims)
+
+	issuer := v.Issuer
+	if tid, ok := claims.Raw["tid"].(string); ok {
+		issuer = strings.Replace(issuer, "{tenantid}", tid, -1)
+	}
+	// google may omit the scheme
+	if claims.Issuer != issuer && "https://"+claims.Issuer != issuer {
+		err = NewError("id_token: iss does not match", 401)
+		return
+	}
+
+	var audience bool
+	for _, aud := range claims.Audience {
+		if aud == v.ClientID {
+			audience = true
+			break
+		}
+	}
+	if !audience {
+		err = NewError("id_token: aud does not match", 401)
+		return
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != "" && claims.AuthorizedParty != v.ClientID {
+		err = NewError("id_token: azp does not match", 401)
+		return
+	}
+
+	leeway := v.Leeway
+	if leeway == 0 {
+		leeway = IDTokenLeeway
+	}
+	now := time.Now()
+	if claims.Expired == nil || now.Add(-leeway).After(*claims.Expired) {
+		err = ErrTokenExpired
+		return
+	}
+	if claims.Issued != nil && now.Add(leeway).Before(*claims.Issued) {
+		err = NewError("id_token: iat is in the future", 401)
+		return
+	}
+
+	if nonce != "" && claims.Nonce != nonce {
+		err = NewError("id_token: nonce does not match", 401)
+		return
+	}
+	return
+}
+
+func (v *IDTokenVerifier) verifyJWKS(ctx context.Context, jwt *JWT) (err error) {
+	if v.JWKSURL == "" {
+		err = NewError("id_token: jwks url is empty", 500)
+		return
+	}
+	var jwks *JWKS
+	if jwks, err = FetchJWKS(ctx, v.Client, v.JWKSURL, false); err != nil {
+		return
+	}
+	jwk := jwks.Key(jwt.Header.Kid)
+	if jwk == nil {
+		// keys rotated
+		if jwks, err = FetchJWKS(ctx, v.Client, v.JWKSURL, true); err != nil {
+			return
+		}
+		if jwk = jwks.Key(jwt.Header.Kid); jwk == nil {
+			err = NewError("id_token: key not found: "+jwt.Header.Kid, 401)
+			return
+		}
+	}
+	if !jwk.Allows(jwt.Header.Alg) {
+		err = NewError("id_token: key "+jwk.Kid+" does not allow alg "+jwt.Header.Alg, 401)
+		return
+	}
+	var key interface{}
+	if key, err = jwk.PublicKey(); err != nil {
+		return
+	}
+	err = jwt.Verify(key)
+	return
+}
+
+func parseIDTokenClaims(raw map[string]interface{}) (claims *IDTokenClaims) {
+	claims = &IDTokenClaims{
+		Raw: raw,
+	}
+	if v, ok := raw["iss"].(string); ok {
+		claims.Issuer = v
+	}
+	if v, ok := raw["sub"].(string); ok {
+		claims.Subject = v
+	}
+	switch v := raw["aud"].(type) {
+	case string:
+		claims.Audience = []string{v}
+	case []interface{}:
+		for _, aud := range v {
+			if aud, ok := aud.(string); ok {
+				claims.Audience = append(claims.Audience, aud)
+			}
+		}
+	}
+	if v, ok := raw["azp"].(string); ok {
+		claims.AuthorizedParty = v
+	}
+	if v, ok := raw["nonce"].(string); ok {
+		claims.Nonce = v
+	}
+	if v, ok := raw["email"].(string); ok {
+		claims.Email = v
+	}
+	switch v := raw["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = v
+	case string:
+		claims.EmailVerified = v == "true"
+	}
+	if v, ok := raw["name"].(string); ok {
+		claims.Name = v
+	}
+	if v, ok := raw["given_name"].(string); ok {
+		claims.GivenName = v
+	}
+	if v, ok := raw["family_name"].(string); ok {
+		claims.FamilyName = v
+	}
+	if v, ok := raw["picture"].(string); ok {
+		claims.Picture = v
+	}
+	if v, ok := raw["locale"].(string); ok {
+		claims.Locale = FormatLocale(v)
+	}
+	claims.Expired = numericDate(raw["exp"])
+	claims.Issued = numericDate(raw["iat"])
+	claims.AuthTime = numericDate(raw["auth_time"])
+	return
+}
+
+func numericDate(val interface{}) *time.Time {
+	var seconds float64
+	switch v := val.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return nil
+		}
+		seconds = f
+	case float64:
+		seconds = v
+	default:
+		return nil
+	}
+	t := time.Unix(int64(seconds), 0)
+	return &t
+}
+
+func (c *OAuth2) IDTokenVerifier() *IDTokenVerifier {
+	return &IDTokenVerifier{
+		Issuer:       c.Endpoint.Issuer,
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		JWKSURL:      c.Endpoint.JWKSURL,
+		Algorithms:   c.Endpoint.IDTokenAlgs,
+		Client:       c,
+	}
+}
+
+// CanVerifyIDToken reports whether the endpoint has an issuer and a key source, the JWKS url or HS256 in IDTokenAlgs
+func (c *OAuth2) CanVerifyIDToken() bool {
+	if c.Endpoint.Issuer == "" {
+		return false
+	}
+	return c.Endpoint.JWKSURL != "" || (containsString(c.Endpoint.IDTokenAlgs, "HS256") && c.ClientSecret != "")
+}
+
+// VerifyIDToken verifies token.IDToken, data is the map returned by Authorize and carries the nonce
+func (c *OAuth2) VerifyIDToken(ctx context.Context, token *Token, data map[string]interface{}) (claims *IDTokenClaims, err error) {
+	if token.ClientID != "" && token.ClientID != c.ClientID {
+		err = NewError("Token.ClientID does not match", 500)
+		return
+	}
+	nonce, _ := data["nonce"].(string)
+	claims, err = c.IDTokenVerifier().Verify(ctx, token.IDToken, nonce)
+	return
+}
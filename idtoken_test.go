@@ -0,0 +1,67 @@
+package oauth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIDTokenVerifyIssuerAndHS256(t *testing.T) {
+	claims := func(iss string) map[string]interface{} {
+		return map[string]interface{}{
+			"iss": iss,
+			"sub": "alice",
+			"aud": "client",
+			"exp": time.Now().Add(time.Hour).Unix(),
+			"iat": time.Now().Unix(),
+		}
+	}
+	tests := []struct {
+		name     string
+		verifier IDTokenVerifier
+		claims   map[string]interface{}
+		err      string
+	}{
+		{"HS256 not opted in", IDTokenVerifier{Issuer: "https://issuer.example.com", ClientID: "client", ClientSecret: "secret"}, claims("https://issuer.example.com"), "alg not allowed"},
+		{"HS256 opted in", IDTokenVerifier{Issuer: "https://issuer.example.com", ClientID: "client", ClientSecret: "secret", Algorithms: []string{"HS256"}}, claims("https://issuer.example.com"), ""},
+		{"HS256 without secret", IDTokenVerifier{Issuer: "https://issuer.example.com", ClientID: "client", Algorithms: []string{"HS256"}}, claims("https://issuer.example.com"), "client secret is empty"},
+		{"no expected issuer", IDTokenVerifier{ClientID: "client", ClientSecret: "secret", Algorithms: []string{"HS256"}}, claims("https://issuer.example.com"), "issuer is empty"},
+		{"other issuer", IDTokenVerifier{Issuer: "https://issuer.example.com", ClientID: "client", ClientSecret: "secret", Algorithms: []string{"HS256"}}, claims("https://evil.example.com"), "iss does not match"},
+		{"missing iss", IDTokenVerifier{Issuer: "https://issuer.example.com", ClientID: "client", ClientSecret: "secret", Algorithms: []string{"HS256"}}, claims(""), "iss does not match"},
+		{"issuer without scheme", IDTokenVerifier{Issuer: "https://accounts.google.com", ClientID: "client", ClientSecret: "secret", Algorithms: []string{"HS256"}}, claims("accounts.google.com"), ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw, err := SignJWT(JWTHeader{}, test.claims, []byte("secret"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = test.verifier.Verify(context.Background(), raw, "")
+			if test.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Verify() error = %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestIDTokenVerifierAlgorithms(t *testing.T) {
+	client := &OAuth2{Config: Config{ClientID: "client", ClientSecret: "secret", Endpoint: Endpoint{Issuer: "https://issuer.example.com"}}}
+	if algorithms := client.IDTokenVerifier().Algorithms; algorithms != nil {
+		t.Fatalf("Algorithms = %v, want the default", algorithms)
+	}
+	client.Endpoint.IDTokenAlgs = []string{"HS256"}
+	raw, err := SignJWT(JWTHeader{}, map[string]interface{}{"iss": "https://issuer.example.com", "aud": "client", "exp": time.Now().Add(time.Hour).Unix()}, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.VerifyIDToken(context.Background(), &Token{IDToken: raw}, nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,230 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	Event struct {
+		Provider  string
+		Operation string
+		Method    string
+		// URL has no query, it may carry tokens
+		URL    string
+		Status int
+		// ErrorKind is empty on success, the error code when the provider sent one in WWW-Authenticate or in a body read by Config.Response, mapped by Endpoint.ErrorCodes, otherwise a class such as timeout, network, unauthorized, rate_limited, client_error or server_error
+		ErrorKind string
+		Duration  time.Duration
+	}
+
+	// Instrument receives an Event for each request sent by a Transport once the response body is closed, it must be safe for concurrent use
+	Instrument interface {
+		Observe(ctx context.Context, event *Event)
+	}
+
+	// Propagator injects trace context into outgoing requests
+	Propagator interface {
+		Inject(ctx context.Context, header http.Header)
+	}
+
+	// PropagatorFunc adapts a function, such as one calling an OpenTelemetry TextMapPropagator with propagation.HeaderCarrier, to a Propagator
+	PropagatorFunc func(ctx context.Context, header http.Header)
+
+	// TraceContext is a W3C trace context Propagator for the parent set with WithTraceParent, each request gets a new span id
+	TraceContext struct{}
+
+	operationKey struct{}
+
+	errorKindKey struct{}
+
+	// observedBody sends the event of a response when its body is closed, after Config.Response has read the error in it
+	observedBody struct {
+		io.ReadCloser
+		once    sync.Once
+		observe func()
+	}
+
+	traceParentKey struct{}
+
+	traceParent struct {
+		parent string
+		state  string
+	}
+)
+
+const (
+	OperationAuthorize  = "authorize"
+	OperationToken      = "token"
+	OperationRefresh    = "refresh"
+	OperationRevoke     = "revoke"
+	OperationUser       = "user"
+	OperationDevice     = "device"
+	OperationIntrospect = "introspect"
+	OperationAPI        = "api"
+)
+
+// WithOperation names the operation of the requests sent with ctx, otherwise it is guessed from the endpoint url
+func WithOperation(ctx context.Context, operation string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+func (f PropagatorFunc) Inject(ctx context.Context, header http.Header) {
+	f(ctx, header)
+}
+
+// WithTraceParent sets the traceparent and tracestate of the incoming request for TraceContext
+func WithTraceParent(ctx context.Context, parent string, state string) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, &traceParent{
+		parent: parent,
+		state:  state,
+	})
+}
+
+// https://www.w3.org/TR/trace-context/#traceparent-header
+func (TraceContext) Inject(ctx context.Context, header http.Header) {
+	trace, ok := ctx.Value(traceParentKey{}).(*traceParent)
+	if !ok {
+		return
+	}
+	parts := strings.Split(trace.parent, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[3]) != 2 || parts[0] == "ff" {
+		return
+	}
+	span := make([]byte, 8)
+	if _, err := rand.Read(span); err != nil {
+		return
+	}
+	header.Set("traceparent", "00-"+parts[1]+"-"+hex.EncodeToString(span)+"-"+parts[3])
+	if trace.state != "" {
+		header.Set("tracestate", trace.state)
+	}
+}
+
+func (t *Transport) operation(req *http.Request) string {
+	if operation, ok := req.Context().Value(operationKey{}).(string); ok && operation != "" {
+		return operation
+	}
+	c, ok := t.Client.(configurer)
+	if !ok {
+		return OperationAPI
+	}
+	endpoint := c.config().Endpoint
+	u := *req.URL
+	u.RawQuery = ""
+	switch u.String() {
+	case "":
+	case endpoint.RequestURL:
+		return OperationAuthorize
+	case endpoint.AccessTokenURL, endpoint.RefreshTokenURL:
+		if grantType(req) == "refresh_token" {
+			return OperationRefresh
+		}
+		return OperationToken
+	case endpoint.RevokeTokenURL:
+		return OperationRevoke
+	case endpoint.DeviceURL:
+		return OperationDevice
+	case endpoint.IntrospectURL:
+		return OperationIntrospect
+	case endpoint.UserInfoURL:
+		return OperationUser
+	}
+	return OperationAPI
+}
+
+func grantType(req *http.Request) string {
+	if v := req.URL.Query().Get("grant_type"); v != "" {
+		return v
+	}
+	if req.GetBody == nil || req.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	b := make([]byte, 4096)
+	n, _ := body.Read(b)
+	values, _ := url.ParseQuery(string(b[:n]))
+	return values.Get("grant_type")
+}
+
+func (t *Transport) observe(req *http.Request, res *http.Response, err error, duration time.Duration) {
+	event := &Event{
+		Operation: t.operation(req),
+		Method:    req.Method,
+		Duration:  duration,
+	}
+	if t.Client != nil {
+		event.Provider = t.Client.Name()
+	}
+	u := *req.URL
+	u.RawQuery = ""
+	u.User = nil
+	event.URL = u.String()
+
+	switch {
+	case err != nil:
+		var netErr net.Error
+		switch {
+		case errors.Is(err, context.Canceled):
+			event.ErrorKind = "canceled"
+		case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+			event.ErrorKind = "timeout"
+		default:
+			event.ErrorKind = "network"
+		}
+	case res.StatusCode >= 400:
+		event.Status = res.StatusCode
+		if code := authenticateParams(res.Header.Get("WWW-Authenticate"))["error"]; code != "" {
+			event.ErrorKind = canonicalCode(code)
+			break
+		}
+		switch {
+		case res.StatusCode == http.StatusUnauthorized:
+			event.ErrorKind = "unauthorized"
+		case res.StatusCode == http.StatusTooManyRequests:
+			event.ErrorKind = "rate_limited"
+		case res.StatusCode >= 500:
+			event.ErrorKind = "server_error"
+		default:
+			event.ErrorKind = "client_error"
+		}
+	default:
+		event.Status = res.StatusCode
+	}
+	if kind, ok := req.Context().Value(errorKindKey{}).(*atomic.Value); ok {
+		// wechat, qq and weibo send errors with status 200
+		if v, _ := kind.Load().(string); v != "" {
+			event.ErrorKind = v
+		}
+	}
+	t.Instrument.Observe(req.Context(), event)
+}
+
+func (b *observedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.observe)
+	return err
+}
+
+// withErrorKind returns a context where Config.Response reports the error found in a response body to the Event of the request
+func withErrorKind(ctx context.Context) (context.Context, *atomic.Value) {
+	kind := &atomic.Value{}
+	return context.WithValue(ctx, errorKindKey{}, kind), kind
+}
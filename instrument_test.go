@@ -0,0 +1,70 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type recordInstrument struct {
+	mu     sync.Mutex
+	events []*Event
+}
+
+func (r *recordInstrument) Observe(ctx context.Context, event *Event) {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+}
+
+func TestObserveBodyError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   string
+	}{
+		{"success", 200, `{"openid":"o"}`, ""},
+		{"mapped errcode with status 200", 200, `{"errcode":40001,"errmsg":"invalid credential"}`, "token_invalid"},
+		{"unmapped errcode with status 200", 200, `{"errcode":99999,"errmsg":"unknown"}`, "client_error"},
+		{"zero errcode", 200, `{"errcode":0,"openid":"o"}`, ""},
+		{"oauth error code", 400, `{"error":"invalid_grant"}`, "invalid_grant"},
+		{"server error", 503, `{}`, "server_error"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			instrument := &recordInstrument{}
+			client := &OAuth2{
+				Config: Config{
+					Endpoint: Endpoint{
+						Name:       "wechat",
+						Errors:     []string{"errcode"},
+						ErrorCodes: map[string]string{"40001": "token_invalid"},
+					},
+					Instrument: instrument,
+				},
+			}
+			req, err := http.NewRequest("GET", server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			client.Response(ctx, HTTPClient(ctx, client, nil), req)
+			if len(instrument.events) != 1 {
+				t.Fatalf("got %d events, want 1", len(instrument.events))
+			}
+			if event := instrument.events[0]; event.ErrorKind != test.kind || event.Status != test.status {
+				t.Fatalf("event status %d kind %q, want %d %q", event.Status, event.ErrorKind, test.status, test.kind)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Package providertest runs a provider's User against canned API responses
+package providertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+)
+
+type (
+	Case struct {
+		Name string
+		// Status defaults to 200
+		Status int
+		// ContentType defaults to application/json
+		ContentType string
+		Body        string
+		// Paths answers a request path with its own body, other paths get Body
+		Paths map[string]string
+		// ID is the expected user id when Err is nil
+		ID  string
+		Err error
+		// Check inspects the user of a case without Err
+		Check func(t *testing.T, user *oauth.User)
+	}
+
+	// rewriteTransport sends every request to the test server, providers with a hardcoded URL included
+	rewriteTransport struct {
+		target *url.URL
+	}
+)
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// Run calls client.User with token once per case, the server answers every request with the case body
+func Run(t *testing.T, client oauth.Client, token *oauth.Token, cases []Case) {
+	t.Helper()
+	for _, test := range cases {
+		test := test
+		t.Run(test.Name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentType := test.ContentType
+				if contentType == "" {
+					contentType = "application/json"
+				}
+				w.Header().Set("Content-Type", contentType)
+				status := test.Status
+				if status == 0 {
+					status = http.StatusOK
+				}
+				w.WriteHeader(status)
+				body, ok := test.Paths[r.URL.Path]
+				if !ok {
+					body = test.Body
+				}
+				w.Write([]byte(body))
+			}))
+			defer server.Close()
+			target, _ := url.Parse(server.URL)
+			ctx := context.WithValue(context.Background(), oauth.ContextHTTPClient, &http.Client{Transport: &rewriteTransport{target: target}})
+
+			user, err := user(ctx, client, token)
+			if test.Err != nil {
+				if !errors.Is(err, test.Err) {
+					t.Fatalf("User() error = %v, want %v", err, test.Err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("User() error = %v", err)
+			}
+			if user.ID != test.ID {
+				t.Fatalf("User().ID = %q, want %q", user.ID, test.ID)
+			}
+			if test.Check != nil {
+				test.Check(t, user)
+			}
+		})
+	}
+}
+
+func user(ctx context.Context, client oauth.Client, token *oauth.Token) (user *oauth.User, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("User() panicked: %v", r)
+		}
+	}()
+	return client.User(ctx, token)
+}
@@ -0,0 +1,191 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	Introspection struct {
+		Active    bool                   `json:"active"`
+		Scopes    []string               `json:"scopes,omitempty"`
+		ClientID  string                 `json:"client_id,omitempty"`
+		Username  string                 `json:"username,omitempty"`
+		TokenType string                 `json:"token_type,omitempty"`
+		Subject   string                 `json:"sub,omitempty"`
+		Audience  []string               `json:"aud,omitempty"`
+		Issuer    string                 `json:"iss,omitempty"`
+		Raw       map[string]interface{} `json:"raw,omitempty"`
+		Expired   *time.Time             `json:"expired,omitempty"`
+		Issued    *time.Time             `json:"issued,omitempty"`
+	}
+
+	IntrospectionCache struct {
+		TTL     time.Duration
+		mu      sync.Mutex
+		entries map[string]*introspectionEntry
+	}
+
+	introspectionEntry struct {
+		introspection *Introspection
+		expired       time.Time
+	}
+)
+
+// https://tools.ietf.org/html/rfc7662#section-2
+func (c *OAuth2) IntrospectToken(ctx context.Context, token string, values url.Values) (introspection *Introspection, err error) {
+	if c.Endpoint.IntrospectURL == "" {
+		err = NewError("Token introspection is not supported", 500)
+		return
+	}
+
+	var key string
+	if c.IntrospectionCache != nil {
+		sum := sha256.Sum256([]byte(c.Endpoint.IntrospectURL + "\n" + token))
+		key = hex.EncodeToString(sum[:])
+		if introspection = c.IntrospectionCache.get(key); introspection != nil {
+			return
+		}
+	}
+
+	AppendValues := url.Values{
+		"token": {token},
+	}
+	values = MergeValues(true, nil, values, AppendValues)
+
+	var req *http.Request
+	if req, err = http.NewRequest("POST", c.Endpoint.IntrospectURL, strings.NewReader(values.Encode())); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	httpClient := HTTPClient(ctx, c, nil)
+	var raw map[string]interface{}
+	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+		return
+	}
+
+	introspection = &Introspection{
+		Raw: raw,
+	}
+	switch v := raw["active"].(type) {
+	case bool:
+		introspection.Active = v
+	case string:
+		introspection.Active = v == "true"
+	}
+	if v, ok := raw["scope"].(string); ok && v != "" {
+		introspection.Scopes = regexpScopeSep.Split(v, -1)
+	}
+	if v, ok := raw["client_id"].(string); ok {
+		introspection.ClientID = v
+	}
+	if v, ok := raw["username"].(string); ok {
+		introspection.Username = v
+	}
+	if v, ok := raw["token_type"].(string); ok {
+		introspection.TokenType = v
+	}
+	if v, ok := raw["sub"].(string); ok {
+		introspection.Subject = v
+	}
+	if v, ok := raw["iss"].(string); ok {
+		introspection.Issuer = v
+	}
+	switch v := raw["aud"].(type) {
+	case string:
+		introspection.Audience = []string{v}
+	case []interface{}:
+		for _, aud := range v {
+			if aud, ok := aud.(string); ok {
+				introspection.Audience = append(introspection.Audience, aud)
+			}
+		}
+	}
+	introspection.Expired = numericDate(raw["exp"])
+	introspection.Issued = numericDate(raw["iat"])
+
+	if introspection.Active && introspection.Expired != nil && introspection.Expired.Before(time.Now()) {
+		introspection.Active = false
+	}
+
+	if c.IntrospectionCache != nil {
+		c.IntrospectionCache.put(key, introspection)
+	}
+	return
+}
+
+func (i Introspection) Copy() (introspection *Introspection) {
+	if i.Scopes != nil {
+		scopes := make([]string, len(i.Scopes))
+		copy(scopes, i.Scopes)
+		i.Scopes = scopes
+	}
+	if i.Audience != nil {
+		audience := make([]string, len(i.Audience))
+		copy(audience, i.Audience)
+		i.Audience = audience
+	}
+	if i.Raw != nil {
+		raw := make(map[string]interface{}, len(i.Raw))
+		for key, val := range i.Raw {
+			raw[key] = val
+		}
+		i.Raw = raw
+	}
+	introspection = &i
+	return
+}
+
+func NewIntrospectionCache(ttl time.Duration) *IntrospectionCache {
+	return &IntrospectionCache{
+		TTL: ttl,
+	}
+}
+
+func (c *IntrospectionCache) get(key string) (introspection *Introspection) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	if now.After(entry.expired) {
+		delete(c.entries, key)
+		return
+	}
+	// callers may modify the result, the entry keeps its own copy
+	introspection = entry.introspection.Copy()
+	return
+}
+
+func (c *IntrospectionCache) put(key string, introspection *Introspection) {
+	now := time.Now()
+	expired := now.Add(c.TTL)
+	// never cache an active token beyond its expiry
+	if introspection.Active && introspection.Expired != nil && introspection.Expired.Before(expired) {
+		expired = *introspection.Expired
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]*introspectionEntry{}
+	}
+	for k, entry := range c.entries {
+		if now.After(entry.expired) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = &introspectionEntry{
+		introspection: introspection.Copy(),
+		expired:       expired,
+	}
+}
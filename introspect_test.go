@@ -0,0 +1,93 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIntrospectTokenCache(t *testing.T) {
+	var count int32
+	exp := time.Now().Add(time.Hour).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"active":true,"scope":"read write","aud":["api"],"sub":"u1","exp":` + strconv.FormatInt(exp, 10) + `}`))
+	}))
+	defer server.Close()
+	client := &OAuth2{Config: Config{
+		ClientID:           "client",
+		Endpoint:           Endpoint{IntrospectURL: server.URL},
+		IntrospectionCache: NewIntrospectionCache(time.Minute),
+	}}
+
+	introspection, err := client.IntrospectToken(context.Background(), "t1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !introspection.Active || introspection.Subject != "u1" || len(introspection.Scopes) != 2 || introspection.Expired.Unix() != exp {
+		t.Fatalf("IntrospectToken() = %+v", introspection)
+	}
+	introspection.Active = false
+	introspection.Scopes[0] = "admin"
+	introspection.Audience[0] = "other"
+	introspection.Raw["sub"] = "u2"
+
+	cached, err := client.IntrospectToken(context.Background(), "t1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&count); n != 1 {
+		t.Fatalf("%d introspection requests, want 1", n)
+	}
+	if !cached.Active || cached.Scopes[0] != "read" || cached.Audience[0] != "api" || cached.Raw["sub"] != "u1" {
+		t.Fatalf("cached introspection was modified by the caller: %+v", cached)
+	}
+	cached.Scopes[0] = "admin"
+	if again, _ := client.IntrospectToken(context.Background(), "t1", nil); again.Scopes[0] != "read" {
+		t.Fatal("callers share the cached introspection")
+	}
+
+	if _, err = client.IntrospectToken(context.Background(), "t2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&count); n != 2 {
+		t.Fatalf("%d introspection requests, want 2", n)
+	}
+}
+
+func TestIntrospectionCacheTTL(t *testing.T) {
+	cache := NewIntrospectionCache(time.Hour)
+	expired := time.Now().Add(50 * time.Millisecond)
+	cache.put("active", &Introspection{Active: true, Expired: &expired})
+	cache.put("inactive", &Introspection{Active: false})
+	cache.put("no exp", &Introspection{Active: true})
+
+	for _, key := range []string{"active", "inactive", "no exp"} {
+		if cache.get(key) == nil {
+			t.Fatalf("get(%q) = nil before expiry", key)
+		}
+	}
+	time.Sleep(60 * time.Millisecond)
+	// an active token is never cached beyond its exp, even with a longer TTL
+	if introspection := cache.get("active"); introspection != nil {
+		t.Fatalf("get(active) = %+v after exp", introspection)
+	}
+	for _, key := range []string{"inactive", "no exp"} {
+		if cache.get(key) == nil {
+			t.Fatalf("get(%q) = nil within the TTL", key)
+		}
+	}
+
+	cache = NewIntrospectionCache(20 * time.Millisecond)
+	later := time.Now().Add(time.Hour)
+	cache.put("active", &Introspection{Active: true, Expired: &later})
+	time.Sleep(30 * time.Millisecond)
+	if introspection := cache.get("active"); introspection != nil {
+		t.Fatalf("get(active) = %+v after the TTL", introspection)
+	}
+}
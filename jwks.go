@@ -0,0 +1,159 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context/ctxhttp"
+)
+
+type (
+	JWK struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid,omitempty"`
+		Use string `json:"use,omitempty"`
+		Alg string `json:"alg,omitempty"`
+		N   string `json:"n,omitempty"`
+		E   string `json:"e,omitempty"`
+		Crv string `json:"crv,omitempty"`
+		X   string `json:"x,omitempty"`
+		Y   string `json:"y,omitempty"`
+	}
+
+	JWKS struct {
+		Keys []*JWK `json:"keys"`
+	}
+
+	jwksEntry struct {
+		jwks    *JWKS
+		fetched time.Time
+		expired time.Time
+	}
+)
+
+// JWKSCacheTTL is how long a fetched key set is reused
+var JWKSCacheTTL = time.Hour
+
+// JWKSRefreshInterval limits refetching a key set when an unknown kid is seen
+var JWKSRefreshInterval = time.Minute
+
+var jwksCache = struct {
+	sync.Mutex
+	entries map[string]*jwksEntry
+}{
+	entries: map[string]*jwksEntry{},
+}
+
+func (k *JWK) PublicKey() (key interface{}, err error) {
+	switch k.Kty {
+	case "RSA":
+		var n, e []byte
+		if n, err = base64.RawURLEncoding.DecodeString(k.N); err != nil {
+			return
+		}
+		if e, err = base64.RawURLEncoding.DecodeString(k.E); err != nil {
+			return
+		}
+		key = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			err = NewError("jwk: curve not support: "+k.Crv, 500)
+			return
+		}
+		var x, y []byte
+		if x, err = base64.RawURLEncoding.DecodeString(k.X); err != nil {
+			return
+		}
+		if y, err = base64.RawURLEncoding.DecodeString(k.Y); err != nil {
+			return
+		}
+		key = &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+	default:
+		err = NewError("jwk: kty not support: "+k.Kty, 500)
+	}
+	return
+}
+
+// Allows reports whether the use and alg of the key, when present, permit verifying a signature of alg
+func (k *JWK) Allows(alg string) bool {
+	if k.Use != "" && k.Use != "sig" {
+		return false
+	}
+	return k.Alg == "" || k.Alg == alg
+}
+
+func (s *JWKS) Key(kid string) *JWK {
+	for _, key := range s.Keys {
+		if kid == "" || key.Kid == kid {
+			if key.Use == "" || key.Use == "sig" {
+				return key
+			}
+		}
+	}
+	return nil
+}
+
+// FetchJWKS returns the cached key set for url, fetching it through the transports of client when missing or expired. refresh forces a refetch, at most once per JWKSRefreshInterval
+func FetchJWKS(ctx context.Context, client Client, url string, refresh bool) (jwks *JWKS, err error) {
+	now := time.Now()
+	jwksCache.Lock()
+	entry := jwksCache.entries[url]
+	jwksCache.Unlock()
+	if entry != nil && now.Before(entry.expired) && (!refresh || now.Sub(entry.fetched) < JWKSRefreshInterval) {
+		jwks = entry.jwks
+		return
+	}
+
+	var req *http.Request
+	if req, err = http.NewRequest("GET", url, nil); err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/json")
+	var res *http.Response
+	if res, err = ctxhttp.Do(ctx, UnsignedHTTPClient(ctx, client), req); err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		err = NewError(fmt.Sprintf("jwks: status code error: %d", res.StatusCode), 502)
+		return
+	}
+	jwks = &JWKS{}
+	if err = json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(jwks); err != nil {
+		return
+	}
+
+	jwksCache.Lock()
+	jwksCache.entries[url] = &jwksEntry{
+		jwks:    jwks,
+		fetched: now,
+		expired: now.Add(JWKSCacheTTL),
+	}
+	jwksCache.Unlock()
+	return
+}
@@ -0,0 +1,275 @@
+package oauth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strings"
+)
+
+type (
+	JWTHeader struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid,omitempty"`
+		Typ string `json:"typ,omitempty"`
+	}
+
+	JWT struct {
+		Header    JWTHeader
+		Claims    map[string]interface{}
+		Signed    []byte
+		Signature []byte
+	}
+)
+
+func ParseJWT(raw string) (jwt *JWT, err error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		err = NewError("jwt: malformed token", 401)
+		return
+	}
+	jwt = &JWT{
+		Signed: []byte(parts[0] + "." + parts[1]),
+	}
+
+	var b []byte
+	if b, err = base64.RawURLEncoding.DecodeString(parts[0]); err != nil {
+		err = NewError("jwt: malformed header", 401)
+		return
+	}
+	if err = json.Unmarshal(b, &jwt.Header); err != nil {
+		err = NewError("jwt: malformed header", 401)
+		return
+	}
+
+	if b, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+		err = NewError("jwt: malformed claims", 401)
+		return
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err = decoder.Decode(&jwt.Claims); err != nil {
+		err = NewError("jwt: malformed claims", 401)
+		return
+	}
+
+	if jwt.Signature, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		err = NewError("jwt: malformed signature", 401)
+		return
+	}
+	return
+}
+
+func jwtHash(alg string) (hash crypto.Hash, ok bool) {
+	if len(alg) != 5 {
+		return
+	}
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return
+	}
+	ok = true
+	return
+}
+
+// esBitSize is the curve size required by an ES* alg
+func esBitSize(alg string) int {
+	switch alg {
+	case "ES256":
+		return 256
+	case "ES384":
+		return 384
+	case "ES512":
+		return 521
+	}
+	return 0
+}
+
+// Verify checks the signature with key, which is a []byte secret for HS*, *rsa.PublicKey for RS*/PS* or *ecdsa.PublicKey for ES*
+func (t *JWT) Verify(key interface{}) (err error) {
+	hash, ok := jwtHash(t.Header.Alg)
+	if !ok {
+		err = NewError("jwt: alg not support: "+t.Header.Alg, 401)
+		return
+	}
+
+	invalid := NewError("jwt: invalid signature", 401)
+
+	if t.Header.Alg[:2] == "HS" {
+		secret, ok := key.([]byte)
+		if !ok {
+			err = invalid
+			return
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(t.Signed)
+		if !hmac.Equal(mac.Sum(nil), t.Signature) {
+			err = invalid
+		}
+		return
+	}
+
+	h := hash.New()
+	h.Write(t.Signed)
+	digest := h.Sum(nil)
+
+	switch t.Header.Alg[:2] {
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(pub, hash, digest, t.Signature) != nil {
+			err = invalid
+		}
+	case "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPSS(pub, hash, digest, t.Signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) != nil {
+			err = invalid
+		}
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve.Params().BitSize != esBitSize(t.Header.Alg) {
+			// a P-256 key must not verify an ES384 header
+			err = invalid
+			return
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(t.Signature) != size*2 {
+			err = invalid
+			return
+		}
+		r := new(big.Int).SetBytes(t.Signature[:size])
+		s := new(big.Int).SetBytes(t.Signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			err = invalid
+		}
+	default:
+		err = NewError("jwt: alg not support: "+t.Header.Alg, 401)
+	}
+	return
+}
+
+// SignJWT signs claims with key, a []byte secret, *rsa.PrivateKey or *ecdsa.PrivateKey. header.Alg defaults to HS256, RS256 or ES256/384/512 by key type
+func SignJWT(header JWTHeader, claims map[string]interface{}, key interface{}) (raw string, err error) {
+	if header.Alg == "" {
+		switch key := key.(type) {
+		case []byte:
+			header.Alg = "HS256"
+		case *rsa.PrivateKey:
+			header.Alg = "RS256"
+		case *ecdsa.PrivateKey:
+			switch key.Curve.Params().BitSize {
+			case 384:
+				header.Alg = "ES384"
+			case 521:
+				header.Alg = "ES512"
+			default:
+				header.Alg = "ES256"
+			}
+		default:
+			err = NewError("jwt: key not support", 500)
+			return
+		}
+	}
+	if header.Typ == "" {
+		header.Typ = "JWT"
+	}
+	hash, ok := jwtHash(header.Alg)
+	if !ok {
+		err = NewError("jwt: alg not support: "+header.Alg, 500)
+		return
+	}
+
+	var b []byte
+	if b, err = json.Marshal(header); err != nil {
+		return
+	}
+	signed := base64.RawURLEncoding.EncodeToString(b)
+	if b, err = json.Marshal(claims); err != nil {
+		return
+	}
+	signed += "." + base64.RawURLEncoding.EncodeToString(b)
+
+	var signature []byte
+	if header.Alg[:2] == "HS" {
+		secret, ok := key.([]byte)
+		if !ok {
+			err = NewError("jwt: key does not match alg "+header.Alg, 500)
+			return
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	} else {
+		h := hash.New()
+		h.Write([]byte(signed))
+		digest := h.Sum(nil)
+		switch header.Alg[:2] {
+		case "RS", "PS":
+			priv, ok := key.(*rsa.PrivateKey)
+			if !ok {
+				err = NewError("jwt: key does not match alg "+header.Alg, 500)
+				return
+			}
+			if header.Alg[:2] == "RS" {
+				signature, err = rsa.SignPKCS1v15(rand.Reader, priv, hash, digest)
+			} else {
+				signature, err = rsa.SignPSS(rand.Reader, priv, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+			}
+			if err != nil {
+				return
+			}
+		case "ES":
+			priv, ok := key.(*ecdsa.PrivateKey)
+			if !ok {
+				err = NewError("jwt: key does not match alg "+header.Alg, 500)
+				return
+			}
+			var r, s *big.Int
+			if r, s, err = ecdsa.Sign(rand.Reader, priv, digest); err != nil {
+				return
+			}
+			size := (priv.Curve.Params().BitSize + 7) / 8
+			signature = make([]byte, size*2)
+			r.FillBytes(signature[:size])
+			s.FillBytes(signature[size:])
+		default:
+			err = NewError("jwt: alg not support: "+header.Alg, 500)
+			return
+		}
+	}
+	raw = signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return
+}
+
+// ParsePrivateKey parses a PEM encoded PKCS#1, PKCS#8 or SEC 1 private key
+func ParsePrivateKey(b []byte) (key interface{}, err error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		err = NewError("private key is not PEM encoded", 500)
+		return
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	return
+}
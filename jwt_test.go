@@ -0,0 +1,65 @@
+package oauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestJWTVerifyCurve(t *testing.T) {
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		alg    string
+		sign   *ecdsa.PrivateKey
+		verify *ecdsa.PublicKey
+		ok     bool
+	}{
+		{"ES256 with P-256", "ES256", p256, &p256.PublicKey, true},
+		{"ES384 with P-384", "ES384", p384, &p384.PublicKey, true},
+		{"ES384 signed by P-256", "ES384", p256, &p256.PublicKey, false},
+		{"ES256 verified by P-384", "ES256", p256, &p384.PublicKey, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw, err := SignJWT(JWTHeader{Alg: test.alg}, map[string]interface{}{"sub": "1"}, test.sign)
+			if err != nil {
+				t.Fatal(err)
+			}
+			jwt, err := ParseJWT(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = jwt.Verify(test.verify); (err == nil) != test.ok {
+				t.Fatalf("Verify() = %v, want ok %v", err, test.ok)
+			}
+		})
+	}
+}
+
+func TestJWKAllows(t *testing.T) {
+	tests := []struct {
+		jwk JWK
+		alg string
+		ok  bool
+	}{
+		{JWK{}, "RS256", true},
+		{JWK{Use: "sig", Alg: "RS256"}, "RS256", true},
+		{JWK{Use: "enc"}, "RS256", false},
+		{JWK{Alg: "ES256"}, "ES384", false},
+	}
+	for _, test := range tests {
+		if ok := test.jwk.Allows(test.alg); ok != test.ok {
+			t.Errorf("%+v.Allows(%s) = %v, want %v", test.jwk, test.alg, ok, test.ok)
+		}
+	}
+}
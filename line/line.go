@@ -12,6 +12,12 @@ type (
 	Client struct {
 		oauth.OAuth2
 	}
+
+	userResponse struct {
+		UserID      oauth.ID `json:"userId"`
+		DisplayName string   `json:"displayName"`
+		PictureURL  string   `json:"pictureUrl"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -22,9 +28,30 @@ var Endpoint = oauth.Endpoint{
 	APIURL:         "https://api.line.me/v2",
 	ClientHeader:   "Basic",
 	TokenHeader:    "Bearer",
+	PKCE:           oauth.PKCES256,
+	Issuer:         "https://access.line.me",
+	JWKSURL:        "https://api.line.me/oauth2/v2.1/certs",
+	// web login id tokens are HS256 signed with the channel secret
+	IDTokenAlgs: []string{"HS256", "ES256"},
+}
+
+func init() {
+	oauth.Register("line", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
 }
 
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
 	var req *http.Request
 	if req, err = http.NewRequest("GET", c.Endpoint.APIURL+"/profile", nil); err != nil {
@@ -32,22 +59,22 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 	}
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
 		return
 	}
-
-	user = &oauth.User{
-		ID:      raw["userId"].(string),
-		Raw:     raw,
-		Updated: &now,
+	if res.UserID == "" {
+		err = oauth.UnexpectedResponse(raw, "userId is missing or invalid")
+		return
 	}
 
-	if v, ok := raw["displayName"].(string); ok {
-		user.Nickname = v
-	}
-	if v, ok := raw["pictureUrl"].(string); ok {
-		user.Avatar = v
+	user = &oauth.User{
+		ID:       res.UserID.String(),
+		Nickname: res.DisplayName,
+		Avatar:   res.PictureURL,
+		Raw:      raw,
+		Updated:  &now,
 	}
 	return
 }
@@ -0,0 +1,30 @@
+package line
+
+import (
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token"}, []providertest.Case{
+		{Name: "missing userId", Body: `{"displayName":"a","pictureUrl":"https://profile.line-scdn.net/a"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "string userId", Body: `{"userId":"U4af4980629","displayName":"a","pictureUrl":"https://profile.line-scdn.net/a"}`, ID: "U4af4980629", Check: func(t *testing.T, user *oauth.User) {
+			if user.Nickname != "a" || user.Avatar != "https://profile.line-scdn.net/a" {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "number userId", Body: `{"userId":1234567890123456789}`, ID: "1234567890123456789"},
+		{Name: "float userId", Body: `{"userId":1.5}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "user_id instead of userId", Body: `{"user_id":"U4af4980629"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"userId":"U1","displayName":5,"pictureUrl":{"url":"https://profile.line-scdn.net/a"}}`, ID: "U1", Check: func(t *testing.T, user *oauth.User) {
+			if user.Nickname != "" || user.Avatar != "" {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "error with status 200", Body: `{"error":"invalid_token","error_description":"The access token expired"}`, Err: oauth.ErrTokenInvalid},
+	})
+}
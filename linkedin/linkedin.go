@@ -13,6 +13,20 @@ type (
 	Client struct {
 		oauth.OAuth2
 	}
+
+	userResponse struct {
+		ID               oauth.ID `json:"id"`
+		FirstName        string   `json:"firstName"`
+		LastName         string   `json:"lastName"`
+		FormattedName    string   `json:"formattedName"`
+		PublicProfileURL string   `json:"publicProfileUrl"`
+		EmailAddress     string   `json:"emailAddress"`
+		Location         struct {
+			Country struct {
+				Code string `json:"code"`
+			} `json:"country"`
+		} `json:"location"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -26,6 +40,21 @@ var Endpoint = oauth.Endpoint{
 	TokenHeader:     "Bearer",
 }
 
+func init() {
+	oauth.Register("linkedin", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
+}
+
 func (c *Client) Signature(req *http.Request, token *oauth.Token, values url.Values) (err error) {
 	if req.Header.Get("X-Li-Format") == "" {
 		req.Header.Set("X-Li-Format", "json")
@@ -35,6 +64,7 @@ func (c *Client) Signature(req *http.Request, token *oauth.Token, values url.Val
 }
 
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
 	var req *http.Request
 	if req, err = http.NewRequest("GET", c.Endpoint.APIURL+"/people/~:(id,first-name,last-name,maiden-name,formatted-name,phonetic-first-name,phonetic-last-name,formatted-phonetic-name,headline,location,industry,current-share,num-connections,num-connections-capped,summary,specialties,positions,picture-url,picture-urls::(original),site-standard-profile-request,api-standard-profile-request,public-profile-url,email-address)", nil); err != nil {
@@ -43,46 +73,35 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
 		return
 	}
-
-	user = &oauth.User{
-		ID:      raw["id"].(string),
-		Raw:     raw,
-		Updated: &now,
+	if res.ID == "" {
+		err = oauth.UnexpectedResponse(raw, "id is missing or invalid")
+		return
 	}
 
-	if v, ok := raw["lastName"].(string); ok {
-		user.FamilyName = v
-	}
-	if v, ok := raw["firstName"].(string); ok {
-		user.GivenName = v
-	}
-	if v, ok := raw["formattedName"].(string); ok {
-		user.Name = v
+	user = &oauth.User{
+		ID:         res.ID.String(),
+		Name:       res.FormattedName,
+		GivenName:  res.FirstName,
+		FamilyName: res.LastName,
+		Link:       res.PublicProfileURL,
+		Raw:        raw,
+		Updated:    &now,
 	}
 
-	if v, ok := raw["publicProfileUrl"].(string); ok {
-		user.Link = v
-	}
-	if v, ok := raw["emailAddress"].(string); ok && v != "" {
-		if user.Auths == nil {
-			user.Auths = make([]*oauth.Auth, 0)
-		}
+	if res.EmailAddress != "" {
 		user.Auths = append(user.Auths, &oauth.Auth{
 			Type:     "email",
-			Value:    v,
+			Value:    res.EmailAddress,
 			Verified: true,
 		})
 	}
-	if location, ok := raw["location"].(map[string]interface{}); ok && location != nil {
-		if country, ok := location["country"].(map[string]interface{}); ok && country != nil {
-			if code, ok := country["code"].(string); ok {
-				user.Locale = oauth.FormatLocale(code)
-			}
-		}
+	if res.Location.Country.Code != "" {
+		user.Locale = oauth.FormatLocale(res.Location.Country.Code)
 	}
 	return
 }
@@ -0,0 +1,40 @@
+package linkedin
+
+import (
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	locale := func(want string) func(t *testing.T, user *oauth.User) {
+		return func(t *testing.T, user *oauth.User) {
+			if user.Locale != want {
+				t.Fatalf("User().Locale = %q, want %q", user.Locale, want)
+			}
+		}
+	}
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token"}, []providertest.Case{
+		{Name: "missing id", Body: `{"firstName":"Frodo","emailAddress":"frodo@example.com"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "string id", Body: `{"id":"1R2RtA","firstName":"Frodo","lastName":"Baggins","emailAddress":"frodo@example.com","location":{"country":{"code":"us"}}}`, ID: "1R2RtA", Check: func(t *testing.T, user *oauth.User) {
+			if user.GivenName != "Frodo" || user.Locale != "us" || len(user.Auths) != 1 {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "number id", Body: `{"id":1234567890123456789}`, ID: "1234567890123456789"},
+		{Name: "float id", Body: `{"id":1.5}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"id":"1","firstName":{"localized":{"en_US":"Frodo"}},"lastName":5,"emailAddress":["frodo@example.com"],"publicProfileUrl":true}`, ID: "1", Check: func(t *testing.T, user *oauth.User) {
+			if user.GivenName != "" || user.FamilyName != "" || len(user.Auths) != 0 {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "location as a string", Body: `{"id":"1","location":"us"}`, ID: "1", Check: locale("")},
+		{Name: "country as a string", Body: `{"id":"1","location":{"country":"us"}}`, ID: "1", Check: locale("")},
+		{Name: "country code as a number", Body: `{"id":"1","location":{"country":{"code":1}}}`, ID: "1", Check: locale("")},
+		{Name: "country code with empty segments", Body: `{"id":"1","location":{"country":{"code":"-us-x"}}}`, ID: "1", Check: locale("us-X")},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "error with status 200", Body: `{"error":"invalid_token","error_description":"The token used in the request has expired"}`, Err: oauth.ErrTokenInvalid},
+	})
+}
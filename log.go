@@ -0,0 +1,207 @@
+package oauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Logger is implemented by *slog.Logger
+	Logger interface {
+		Log(ctx context.Context, level slog.Level, msg string, args ...interface{})
+	}
+
+	LogOptions struct {
+		Logger Logger
+		// Level of the request and response events
+		Level slog.Level
+		// MaxBody caps logged bodies after redaction, 0 omits bodies
+		MaxBody int
+		// Redact lists the header, parameter and JSON keys whose values are hidden, nil means RedactKeys
+		Redact []string
+	}
+
+	levelEnabler interface {
+		Enabled(ctx context.Context, level slog.Level) bool
+	}
+)
+
+const redacted = "[REDACTED]"
+
+// RedactKeys are the secrets, tokens, codes and PII hidden by default. Keys match case insensitively
+var RedactKeys = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie",
+	"client_secret", "client_assertion", "assertion", "password",
+	"access_token", "refresh_token", "id_token", "token", "subject_token", "actor_token",
+	"code", "code_verifier", "device_code", "user_code",
+	"oauth_token", "oauth_token_secret", "oauth_verifier", "oauth_signature",
+	"email", "phone", "phone_number", "mobile", "birthday", "address",
+}
+
+var debugLog = &LogOptions{
+	Logger:  slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	Level:   slog.LevelDebug,
+	MaxBody: 4096,
+}
+
+func (o *LogOptions) enabled(ctx context.Context) bool {
+	if e, ok := o.Logger.(levelEnabler); ok {
+		return e.Enabled(ctx, o.Level)
+	}
+	return true
+}
+
+func (o *LogOptions) logRequest(ctx context.Context, provider string, req *http.Request) {
+	args := []interface{}{
+		"provider", provider,
+		"method", req.Method,
+		"url", o.redactURL(req.URL),
+		"header", o.redactHeader(req.Header),
+	}
+	if o.MaxBody > 0 && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			b, _ := ioutil.ReadAll(io.LimitReader(body, 1<<20))
+			body.Close()
+			args = append(args, "body", o.redactBody(req.Header.Get("Content-Type"), b))
+		}
+	}
+	o.Logger.Log(ctx, o.Level, "oauth request", args...)
+}
+
+func (o *LogOptions) logResponse(ctx context.Context, provider string, req *http.Request, res *http.Response, err error, duration time.Duration) {
+	args := []interface{}{
+		"provider", provider,
+		"method", req.Method,
+		"url", o.redactURL(req.URL),
+		"duration", duration,
+	}
+	if err != nil {
+		args = append(args, "error", err.Error())
+		o.Logger.Log(ctx, o.Level, "oauth response", args...)
+		return
+	}
+	args = append(args, "status", res.StatusCode, "header", o.redactHeader(res.Header))
+	if o.MaxBody > 0 && res.Body != nil {
+		// the body is read ahead and put back for the caller
+		b, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(b), res.Body), res.Body}
+		args = append(args, "body", o.redactBody(res.Header.Get("Content-Type"), b))
+	}
+	o.Logger.Log(ctx, o.Level, "oauth response", args...)
+}
+
+func (o *LogOptions) redactKey(key string) bool {
+	keys := o.Redact
+	if keys == nil {
+		keys = RedactKeys
+	}
+	for _, k := range keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *LogOptions) redactURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	if c.RawQuery != "" {
+		c.RawQuery = o.redactValues(c.Query()).Encode()
+	}
+	return c.String()
+}
+
+func (o *LogOptions) redactHeader(header http.Header) http.Header {
+	h := make(http.Header, len(header))
+	for key, values := range header {
+		if !o.redactKey(key) {
+			h[key] = values
+			continue
+		}
+		for _, value := range values {
+			// keep the scheme of Authorization
+			if i := strings.IndexByte(value, ' '); i != -1 && strings.HasSuffix(key, "Authorization") {
+				value = value[:i+1] + redacted
+			} else {
+				value = redacted
+			}
+			h[key] = append(h[key], value)
+		}
+	}
+	return h
+}
+
+func (o *LogOptions) redactValues(values url.Values) url.Values {
+	v := make(url.Values, len(values))
+	for key, vals := range values {
+		if o.redactKey(key) {
+			v[key] = []string{redacted}
+		} else {
+			v[key] = vals
+		}
+	}
+	return v
+}
+
+// redactBody logs JSON (JSONP) and form bodies with redacted values, other bodies only by size
+func (o *LogOptions) redactBody(contentType string, b []byte) string {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return ""
+	}
+	b = regexpCallback.ReplaceAll(b, []byte("${1}"))
+	typ, _, _ := mime.ParseMediaType(contentType)
+
+	var out string
+	var v interface{}
+	switch {
+	case (b[0] == '{' || b[0] == '[') && json.Unmarshal(b, &v) == nil:
+		j, _ := json.Marshal(o.redactJSON(v))
+		out = string(j)
+	case typ == "application/x-www-form-urlencoded" || typ == "text/plain" || !bytes.ContainsAny(b, " <\n"):
+		values, err := url.ParseQuery(string(b))
+		if err != nil {
+			return "[" + strconv.Itoa(len(b)) + " bytes]"
+		}
+		out = o.redactValues(values).Encode()
+	default:
+		return "[" + strconv.Itoa(len(b)) + " bytes]"
+	}
+	if len(out) > o.MaxBody {
+		out = out[:o.MaxBody] + "..."
+	}
+	return out
+}
+
+func (o *LogOptions) redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if o.redactKey(key) {
+				v[key] = redacted
+			} else {
+				v[key] = o.redactJSON(val)
+			}
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = o.redactJSON(val)
+		}
+	}
+	return v
+}
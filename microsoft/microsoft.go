@@ -14,6 +14,16 @@ type (
 	Client struct {
 		oauth.OAuth2
 	}
+
+	userResponse struct {
+		ID                oauth.ID `json:"id"`
+		DisplayName       string   `json:"displayName"`
+		GivenName         string   `json:"givenName"`
+		Surname           string   `json:"surname"`
+		Mail              string   `json:"mail"`
+		UserPrincipalName string   `json:"userPrincipalName"`
+		MobilePhone       string   `json:"mobilePhone"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -22,10 +32,36 @@ var Endpoint = oauth.Endpoint{
 	AccessTokenURL:  "https://login.microsoftonline.com/common/oauth2/v2.0/token",
 	RefreshTokenURL: "https://login.microsoftonline.com/common/oauth2/v2.0/token",
 	APIURL:          "https://graph.microsoft.com/v1.0",
+	DeviceURL:       "https://login.microsoftonline.com/common/oauth2/v2.0/devicecode",
 	TokenHeader:     "Bearer",
+	PKCE:            oauth.PKCES256,
+	Issuer:          "https://login.microsoftonline.com/{tenantid}/v2.0",
+	JWKSURL:         "https://login.microsoftonline.com/common/discovery/v2.0/keys",
+	// https://learn.microsoft.com/graph/errors
+	ErrorCodes: map[string]string{
+		"InvalidAuthenticationToken": "token_invalid",
+		"TooManyRequests":            "rate_limited",
+		"activityLimitReached":       "rate_limited",
+	},
+}
+
+func init() {
+	oauth.Register("microsoft", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
 }
 
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
 	var req *http.Request
 	if req, err = http.NewRequest("GET", c.Endpoint.APIURL+"/me", nil); err != nil {
@@ -33,54 +69,43 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 	}
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
 		return
 	}
-
-	user = &oauth.User{
-		ID:      raw["id"].(string),
-		Raw:     raw,
-		Updated: &now,
+	if res.ID == "" {
+		err = oauth.UnexpectedResponse(raw, "id is missing or invalid")
+		return
 	}
 
-	if v, ok := raw["displayName"].(string); ok && v != "" {
-		user.Nickname = v
-	}
-	if v, ok := raw["givenName"].(string); ok {
-		user.GivenName = v
-	}
-	if v, ok := raw["surname"].(string); ok {
-		user.FamilyName = v
+	user = &oauth.User{
+		ID:         res.ID.String(),
+		Nickname:   res.DisplayName,
+		GivenName:  res.GivenName,
+		FamilyName: res.Surname,
+		Raw:        raw,
+		Updated:    &now,
 	}
 
-	if v, ok := raw["mail"].(string); ok && v != "" {
-		if user.Auths == nil {
-			user.Auths = make([]*oauth.Auth, 0)
-		}
+	if res.Mail != "" {
 		user.Auths = append(user.Auths, &oauth.Auth{
 			Type:     "email",
-			Value:    v,
+			Value:    res.Mail,
 			Verified: true,
 		})
 	}
-	if v, ok := raw["userPrincipalName"].(string); ok && v != "" && strings.IndexAny(v, "@") != -1 {
-		if user.Auths == nil {
-			user.Auths = make([]*oauth.Auth, 0)
-		}
+	if strings.Contains(res.UserPrincipalName, "@") {
 		user.Auths = append(user.Auths, &oauth.Auth{
 			Type:     "email",
-			Value:    v,
+			Value:    res.UserPrincipalName,
 			Verified: false,
 		})
 	}
-	if v, ok := raw["mobilePhone"].(string); ok && v != "" {
-		if user.Auths == nil {
-			user.Auths = make([]*oauth.Auth, 0)
-		}
+	if res.MobilePhone != "" {
 		user.Auths = append(user.Auths, &oauth.Auth{
 			Type:     "mobile_phone",
-			Value:    v,
+			Value:    res.MobilePhone,
 			Verified: true,
 		})
 	}
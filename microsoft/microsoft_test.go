@@ -0,0 +1,36 @@
+package microsoft
+
+import (
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token"}, []providertest.Case{
+		{Name: "missing id", Body: `{"displayName":"Adele Vance","mail":"adele@example.com"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "string id", Body: `{"id":"87d349ed-44d7-43e1-9a83-5f2406dee5bd","displayName":"Adele Vance","mail":"adele@example.com","userPrincipalName":"adele@example.onmicrosoft.com"}`, ID: "87d349ed-44d7-43e1-9a83-5f2406dee5bd", Check: func(t *testing.T, user *oauth.User) {
+			if user.Nickname != "Adele Vance" || len(user.Auths) != 2 {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "number id", Body: `{"id":1234567890123456789}`, ID: "1234567890123456789"},
+		{Name: "float id", Body: `{"id":1.5}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"id":"1","displayName":5,"givenName":[],"mail":{"address":"adele@example.com"},"userPrincipalName":7,"mobilePhone":true}`, ID: "1", Check: func(t *testing.T, user *oauth.User) {
+			if user.Nickname != "" || user.GivenName != "" || len(user.Auths) != 0 {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "null mail", Body: `{"id":"1","mail":null,"userPrincipalName":"adele_example.com#EXT#"}`, ID: "1", Check: func(t *testing.T, user *oauth.User) {
+			if len(user.Auths) != 0 {
+				t.Fatalf("User().Auths = %+v", user.Auths)
+			}
+		}},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "invalid authentication token", Status: 401, Body: `{"error":{"code":"InvalidAuthenticationToken","message":"Access token has expired or is not yet valid."}}`, Err: oauth.ErrTokenInvalid},
+		{Name: "too many requests", Status: 429, Body: `{"error":{"code":"TooManyRequests","message":"Too many requests"}}`, Err: oauth.ErrRateLimited},
+		{Name: "error with status 200", Body: `{"error":"invalid_token","error_description":"Access token has expired."}`, Err: oauth.ErrTokenInvalid},
+	})
+}
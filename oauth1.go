@@ -186,6 +186,21 @@ func (c *OAuth1) ClientCredentialsToken(ctx context.Context, values url.Values)
 	return
 }
 
+func (c *OAuth1) JWTBearerToken(ctx context.Context, claims map[string]interface{}, key interface{}, values url.Values) (token *Token, err error) {
+	err = NewError("Oauth1 does not support", 500)
+	return
+}
+
+func (c *OAuth1) DeviceAuthorize(ctx context.Context, values url.Values) (device *DeviceAuthorization, err error) {
+	err = NewError("Oauth1 does not support", 500)
+	return
+}
+
+func (c *OAuth1) DeviceToken(ctx context.Context, device *DeviceAuthorization, values url.Values) (token *Token, err error) {
+	err = NewError("Oauth1 does not support", 500)
+	return
+}
+
 func (c *OAuth1) RefreshToken(ctx context.Context, oldToken *Token, values url.Values) (newToken *Token, err error) {
 	err = NewError("Oauth1 does not support", 500)
 	return
@@ -259,11 +274,11 @@ func (c *OAuth1) Signature(req *http.Request, token *Token, values url.Values) (
 			}
 		}
 
-		v := strings.NewReader(body.Encode())
-		req.ContentLength = int64(v.Len())
-		req.Body = ioutil.NopCloser(v)
+		encoded := body.Encode()
+		req.ContentLength = int64(len(encoded))
+		req.Body = ioutil.NopCloser(strings.NewReader(encoded))
 		req.GetBody = func() (io.ReadCloser, error) {
-			return req.Body, nil
+			return ioutil.NopCloser(strings.NewReader(encoded)), nil
 		}
 	} else {
 		if values != nil {
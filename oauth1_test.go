@@ -0,0 +1,50 @@
+package oauth
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOAuth1FormBodyLogged(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		received = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	client := &OAuth1{
+		Config: Config{
+			ClientID:     "client",
+			ClientSecret: "secret",
+			Log: &LogOptions{
+				Logger:  slog.New(slog.NewTextHandler(&logs, nil)),
+				MaxBody: 4096,
+			},
+		},
+	}
+	req, err := http.NewRequest("POST", server.URL, strings.NewReader("status=hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	ctx := context.Background()
+	if _, err = client.Response(ctx, HTTPClient(ctx, client, &Token{AccessToken: "token", TokenSecret: "token_secret"}), req); err != nil {
+		t.Fatal(err)
+	}
+	if received != "status=hello" {
+		t.Fatalf("server received body %q, want status=hello", received)
+	}
+	if !strings.Contains(logs.String(), "status=hello") {
+		t.Fatalf("body is not logged: %s", logs.String())
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -46,16 +45,8 @@ func (c *OAuth2) Authorize(ctx context.Context, state string, values url.Values)
 	}
 
 	query := authorizeURL.Query()
-	var scope string
-	if c.Scopes != nil && len(c.Scopes) > 0 {
-		if c.Endpoint.ScopeSep == "" {
-			scope = strings.Join(c.Scopes, " ")
-		} else {
-			scope = strings.Join(c.Scopes, c.Endpoint.ScopeSep)
-		}
-	}
 	defaultValues := url.Values{
-		"scope":         {scope},
+		"scope":         {c.scope()},
 		"redirect_uri":  {c.RedirectURI},
 		"response_type": {"code"},
 	}
@@ -69,9 +60,33 @@ func (c *OAuth2) Authorize(ctx context.Context, state string, values url.Values)
 		"state":     {state},
 	}
 
+	data = map[string]interface{}{}
+
+	if method := c.Endpoint.PKCEMethod(); method != "" {
+		verifier := PKCEVerifier()
+		var challenge string
+		if challenge, err = PKCEChallenge(method, verifier); err != nil {
+			return
+		}
+		AppendValues.Set("code_challenge", challenge)
+		AppendValues.Set("code_challenge_method", method)
+		data["code_verifier"] = verifier
+	}
+
+	if c.CanVerifyIDToken() && containsString(c.Scopes, "openid") {
+		nonce := ""
+		if values != nil {
+			nonce = values.Get("nonce")
+		}
+		if nonce == "" {
+			nonce = RandString(32)
+			AppendValues.Set("nonce", nonce)
+		}
+		data["nonce"] = nonce
+	}
+
 	query = MergeValues(true, query, defaultValues, values, AppendValues)
 
-	data = map[string]interface{}{}
 	authorizeURL.RawQuery = query.Encode()
 	return
 }
@@ -88,6 +103,9 @@ func (c *OAuth2) Exchange(ctx context.Context, query url.Values, data map[string
 		values = url.Values{}
 	}
 	values.Set("code", code)
+	if v, ok := data["code_verifier"].(string); ok && v != "" {
+		values.Set("code_verifier", v)
+	}
 	token, err = c.AccessToken(ctx, values)
 	return
 }
@@ -125,6 +143,44 @@ func (c *OAuth2) ClientCredentialsToken(ctx context.Context, values url.Values)
 	return
 }
 
+// JWTBearerToken signs claims with key and exchanges the assertion (RFC 7523). iss, aud, iat, exp and jti are filled when missing, key defaults to Config.PrivateKey
+func (c *OAuth2) JWTBearerToken(ctx context.Context, claims map[string]interface{}, key interface{}, values url.Values) (token *Token, err error) {
+	if key == nil {
+		if key, err = c.SigningKey(); err != nil {
+			return
+		}
+	}
+
+	now := time.Now()
+	defaultClaims := map[string]interface{}{
+		"iss": c.ClientID,
+		"aud": c.Endpoint.AccessTokenURL,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+		"jti": RandString(32),
+	}
+	for k, v := range claims {
+		defaultClaims[k] = v
+	}
+
+	var assertion string
+	if assertion, err = SignJWT(JWTHeader{Kid: c.KeyID}, defaultClaims, key); err != nil {
+		return
+	}
+
+	defaultValues := url.Values{
+		"scope": {c.scope()},
+	}
+	AppendValues := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+
+	token = &Token{}
+	err = c.RequestToken(ctx, c.Endpoint.AccessTokenURL, token, defaultValues, values, AppendValues)
+	return
+}
+
 func (c *OAuth2) RefreshToken(ctx context.Context, oldToken *Token, values url.Values) (newToken *Token, err error) {
 	if oldToken.RefreshToken == "" {
 		err = NewError("Cannot refresh the token", 500)
@@ -184,19 +240,8 @@ func (c *OAuth2) RevokeToken(ctx context.Context, token *Token, values url.Value
 
 func (c *OAuth2) Signature(req *http.Request, token *Token, values url.Values) (err error) {
 	if token == nil {
-		if c.Endpoint.ClientHeader == "Basic" {
-			req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
-		} else {
-			ClientIDKey := c.Endpoint.ClientIDKey
-			ClientSecret := c.Endpoint.ClientSecretKey
-
-			if ClientIDKey == "" {
-				ClientIDKey = "client_id"
-			}
-			if ClientSecret == "" {
-				ClientSecret = "client_secret"
-			}
-			values = MergeValues(false, values, url.Values{ClientIDKey: {c.ClientID}, ClientSecret: {c.ClientSecret}})
+		if values, err = c.clientAuthentication(req, values); err != nil {
+			return
 		}
 	} else {
 		if token.Expired != nil && token.Expired.Before(time.Now()) {
@@ -255,11 +300,7 @@ func (c *OAuth2) RequestToken(ctx context.Context, urlString string, token *Toke
 	if e == nil {
 		e = raw["expires"]
 	}
-	switch e.(type) {
-	case string:
-		e, _ = strconv.ParseFloat(e.(string), 64)
-	}
-	if s, ok := e.(float64); ok && s != 0 {
+	if s := rawSeconds(e); s != 0 {
 		expired := now.Add(time.Duration(s) * time.Second)
 		token.Expired = &expired
 	}
@@ -295,6 +336,25 @@ func (c *OAuth2) RequestToken(ctx context.Context, urlString string, token *Toke
 	return
 }
 
+func (c *OAuth2) scope() string {
+	if c.Scopes == nil || len(c.Scopes) == 0 {
+		return ""
+	}
+	if c.Endpoint.ScopeSep == "" {
+		return strings.Join(c.Scopes, " ")
+	}
+	return strings.Join(c.Scopes, c.Endpoint.ScopeSep)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func setValues(req *http.Request, values url.Values) (err error) {
 	if req.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
 		b := []byte{}
@@ -311,11 +371,11 @@ func setValues(req *http.Request, values url.Values) (err error) {
 			body.Set(key, val[0])
 		}
 
-		v := strings.NewReader(body.Encode())
-		req.ContentLength = int64(v.Len())
-		req.Body = ioutil.NopCloser(v)
+		encoded := body.Encode()
+		req.ContentLength = int64(len(encoded))
+		req.Body = ioutil.NopCloser(strings.NewReader(encoded))
 		req.GetBody = func() (io.ReadCloser, error) {
-			return req.Body, nil
+			return ioutil.NopCloser(strings.NewReader(encoded)), nil
 		}
 	} else {
 		query := req.URL.Query()
@@ -0,0 +1,183 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/otamoe/oauth-client"
+)
+
+type (
+	Client struct {
+		oauth.OAuth2
+	}
+)
+
+func init() {
+	// the endpoint comes from discovery, see New
+	oauth.Register("oidc", func(config oauth.Config) oauth.Client {
+		if config.Endpoint.Name == "" {
+			config.Endpoint.Name = "oidc"
+		}
+		return &Client{
+			OAuth2: oauth.OAuth2{
+				Config: config,
+			},
+		}
+	})
+}
+
+// New discovers the endpoint of issuer, fields already set in config.Endpoint are kept
+func New(ctx context.Context, issuer string, config oauth.Config) (client *Client, err error) {
+	// discovery goes through the Retry, Log and Instrument of config
+	discoverer := &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
+	if discoverer.Endpoint.Name == "" {
+		discoverer.Endpoint.Name = "oidc"
+	}
+	var endpoint oauth.Endpoint
+	if endpoint, err = oauth.DiscoverEndpoint(ctx, discoverer, issuer); err != nil {
+		return
+	}
+	config.Endpoint = oauth.MergeEndpoint(endpoint, config.Endpoint)
+	client = &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
+	return
+}
+
+func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
+	var raw map[string]interface{}
+	if c.Endpoint.UserInfoURL == "" {
+		// no userinfo endpoint, fall back to the verified id_token claims
+		var claims *oauth.IDTokenClaims
+		if claims, err = c.VerifyIDToken(ctx, token, nil); err != nil {
+			return
+		}
+		raw = claims.Raw
+	} else {
+		var req *http.Request
+		if req, err = http.NewRequest("GET", c.Endpoint.UserInfoURL, nil); err != nil {
+			return
+		}
+		req.Header.Set("Accept", "application/json")
+		httpClient := oauth.HTTPClient(ctx, c, token)
+		if raw, err = c.Response(ctx, httpClient, req); err != nil {
+			return
+		}
+		if token.IDToken != "" {
+			// OIDC Core 5.3.2, the userinfo sub must be the sub of the id_token verified at exchange
+			var jwt *oauth.JWT
+			if jwt, err = oauth.ParseJWT(token.IDToken); err != nil {
+				return
+			}
+			if sub, _ := raw["sub"].(string); sub == "" || sub != jwt.Claims["sub"] {
+				err = oauth.ErrTokenInvalid
+				return
+			}
+		}
+	}
+	user, err = ClaimsUser(raw)
+	return
+}
+
+// ClaimsUser maps the standard claims
+// https://openid.net/specs/openid-connect-core-1_0.html#StandardClaims
+func ClaimsUser(raw map[string]interface{}) (user *oauth.User, err error) {
+	now := time.Now()
+	sub, ok := raw["sub"].(string)
+	if !ok || sub == "" {
+		err = oauth.UnexpectedResponse(raw, "sub is missing or invalid")
+		return
+	}
+
+	user = &oauth.User{
+		ID:      sub,
+		Raw:     raw,
+		Updated: &now,
+	}
+
+	if v, ok := raw["preferred_username"].(string); ok {
+		user.Username = v
+	}
+	if v, ok := raw["nickname"].(string); ok {
+		user.Nickname = v
+	}
+	if v, ok := raw["name"].(string); ok {
+		user.Name = v
+	}
+	if v, ok := raw["given_name"].(string); ok {
+		user.GivenName = v
+	}
+	if v, ok := raw["family_name"].(string); ok {
+		user.FamilyName = v
+	}
+	if v, ok := raw["picture"].(string); ok {
+		user.Avatar = v
+	}
+	if v, ok := raw["profile"].(string); ok {
+		user.Link = v
+	}
+	if v, ok := raw["locale"].(string); ok && v != "" {
+		user.Locale = oauth.FormatLocale(v)
+	}
+	if v, ok := raw["zoneinfo"].(string); ok {
+		user.Timezone = v
+	}
+	if v, ok := raw["gender"].(string); ok {
+		if v == "male" || v == "female" {
+			user.Gender = v
+		}
+	}
+	if v, ok := raw["birthdate"].(string); ok && v != "" {
+		// YYYY-MM-DD, 0000-MM-DD when the year is omitted, or YYYY alone
+		for _, layout := range []string{"2006-01-02", "2006"} {
+			if birthday, err := time.Parse(layout, v); err == nil {
+				user.Birthday = &birthday
+				break
+			}
+		}
+	}
+
+	if v, ok := raw["email"].(string); ok && v != "" {
+		verified := claimBool(raw["email_verified"])
+		if user.Auths == nil {
+			user.Auths = make([]*oauth.Auth, 0)
+		}
+		user.Auths = append(user.Auths, &oauth.Auth{
+			Type:     "email",
+			Value:    v,
+			Verified: verified,
+		})
+	}
+	if v, ok := raw["phone_number"].(string); ok && v != "" {
+		verified := claimBool(raw["phone_number_verified"])
+		if user.Auths == nil {
+			user.Auths = make([]*oauth.Auth, 0)
+		}
+		user.Auths = append(user.Auths, &oauth.Auth{
+			Type:     "mobile_phone",
+			Value:    v,
+			Verified: verified,
+		})
+	}
+	return
+}
+
+// some providers send booleans as strings
+func claimBool(val interface{}) bool {
+	switch v := val.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
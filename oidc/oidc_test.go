@@ -0,0 +1,95 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUserSubject(t *testing.T) {
+	idToken, err := oauth.SignJWT(oauth.JWTHeader{}, map[string]interface{}{"sub": "alice"}, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		idToken string
+		err     error
+	}{
+		{"same sub", `{"sub":"alice","name":"Alice"}`, idToken, nil},
+		{"different sub", `{"sub":"mallory"}`, idToken, oauth.ErrTokenInvalid},
+		{"missing sub", `{"name":"Alice"}`, idToken, oauth.ErrTokenInvalid},
+		{"no id_token", `{"sub":"bob"}`, "", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			client, err := oauth.NewClient("oidc", oauth.Config{
+				ClientID:     "client",
+				ClientSecret: "secret",
+				Endpoint: oauth.Endpoint{
+					UserInfoURL: server.URL,
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			user, err := client.User(context.Background(), &oauth.Token{AccessToken: "token", IDToken: test.idToken})
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("User() error = %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.ID == "" {
+				t.Fatal("User().ID is empty")
+			}
+		})
+	}
+}
+
+func TestUser(t *testing.T) {
+	client, err := oauth.NewClient("oidc", oauth.Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Endpoint: oauth.Endpoint{
+			UserInfoURL: "https://example.com/userinfo",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token"}, []providertest.Case{
+		{Name: "missing sub", Body: `{"name":"a"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "string sub", Body: `{"sub":"1234567890123456789"}`, ID: "1234567890123456789"},
+		{Name: "number sub", Body: `{"sub":1234567890123456789}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "float sub", Body: `{"sub":1.5}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"sub":"1","name":5,"email":[],"email_verified":"yes","picture":{},"locale":1,"birthdate":19900101,"updated_at":"x","address":"x"}`, ID: "1", Check: func(t *testing.T, user *oauth.User) {
+			if user.Name != "" || user.Avatar != "" || user.Locale != "" || user.Birthday != nil || len(user.Auths) != 0 {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "locale with empty segments", Body: `{"sub":"1","locale":"-us-x"}`, ID: "1", Check: func(t *testing.T, user *oauth.User) {
+			if user.Locale != "us-X" {
+				t.Fatalf("User().Locale = %q", user.Locale)
+			}
+		}},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "error with status 200", Body: `{"error":"invalid_token","error_description":"bad token"}`, Err: oauth.ErrTokenInvalid},
+	})
+}
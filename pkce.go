@@ -0,0 +1,39 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// PKCE code challenge methods (RFC 7636)
+const (
+	PKCEPlain = "plain"
+	PKCES256  = "S256"
+	// PKCENone turns PKCE off, it overrides the method of a provider that enables it by default
+	PKCENone = "none"
+)
+
+// PKCEMethod returns the code challenge method of the endpoint, "" when PKCE is off
+func (e Endpoint) PKCEMethod() string {
+	if e.PKCE == PKCENone {
+		return ""
+	}
+	return e.PKCE
+}
+
+func PKCEVerifier() string {
+	return RandString(64)
+}
+
+func PKCEChallenge(method string, verifier string) (challenge string, err error) {
+	switch method {
+	case PKCEPlain:
+		challenge = verifier
+	case PKCES256:
+		sum := sha256.Sum256([]byte(verifier))
+		challenge = base64.RawURLEncoding.EncodeToString(sum[:])
+	default:
+		err = NewError("PKCE method not support: "+method, 500)
+	}
+	return
+}
@@ -0,0 +1,116 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestPKCEChallenge(t *testing.T) {
+	// https://tools.ietf.org/html/rfc7636#appendix-B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	tests := []struct {
+		method    string
+		challenge string
+		err       bool
+	}{
+		{PKCES256, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", false},
+		{PKCEPlain, verifier, false},
+		{"S512", "", true},
+		{"", "", true},
+	}
+	for _, test := range tests {
+		challenge, err := PKCEChallenge(test.method, verifier)
+		if (err != nil) != test.err {
+			t.Fatalf("PKCEChallenge(%q) error = %v", test.method, err)
+		}
+		if challenge != test.challenge {
+			t.Fatalf("PKCEChallenge(%q) = %q, want %q", test.method, challenge, test.challenge)
+		}
+	}
+}
+
+func TestPKCEVerifier(t *testing.T) {
+	verifier := PKCEVerifier()
+	// 43 to 128 characters of [A-Z] / [a-z] / [0-9] / "-" / "." / "_" / "~"
+	if len(verifier) < 43 || len(verifier) > 128 {
+		t.Fatalf("len(PKCEVerifier()) = %d", len(verifier))
+	}
+	for _, r := range verifier {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '.' || r == '_' || r == '~') {
+			t.Fatalf("PKCEVerifier() = %q has %q", verifier, r)
+		}
+	}
+	if PKCEVerifier() == verifier {
+		t.Fatal("PKCEVerifier() returned the same verifier twice")
+	}
+}
+
+func TestPKCEExchange(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"a1","token_type":"bearer"}`))
+	}))
+	defer server.Close()
+	client := &OAuth2{Config: Config{
+		ClientID: "client",
+		Endpoint: Endpoint{AuthorizeURL: server.URL, AccessTokenURL: server.URL, PKCE: PKCES256},
+	}}
+
+	authorizeURL, data, err := client.Authorize(context.Background(), "state", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, _ := data["code_verifier"].(string)
+	challenge, _ := PKCEChallenge(PKCES256, verifier)
+	if verifier == "" || authorizeURL.Query().Get("code_challenge") != challenge {
+		t.Fatalf("code_challenge = %q for verifier %q", authorizeURL.Query().Get("code_challenge"), verifier)
+	}
+
+	if _, err = client.Exchange(context.Background(), url.Values{"code": {"c1"}, "state": {"state"}}, data, nil); err != nil {
+		t.Fatal(err)
+	}
+	if form.Get("code") != "c1" || form.Get("code_verifier") != verifier {
+		t.Fatalf("token request %v", form)
+	}
+}
+
+func TestAuthorizePKCENone(t *testing.T) {
+	base := Endpoint{
+		AuthorizeURL: "https://example.com/authorize",
+		PKCE:         PKCES256,
+	}
+	tests := []struct {
+		name     string
+		override Endpoint
+		method   string
+	}{
+		{"provider default", Endpoint{}, PKCES256},
+		{"plain", Endpoint{PKCE: PKCEPlain}, PKCEPlain},
+		{"none", Endpoint{PKCE: PKCENone}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &OAuth2{Config: Config{ClientID: "client", Endpoint: MergeEndpoint(base, test.override)}}
+			authorizeURL, data, err := client.Authorize(context.Background(), "state", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query := authorizeURL.Query()
+			if got := query.Get("code_challenge_method"); got != test.method {
+				t.Fatalf("code_challenge_method = %q, want %q", got, test.method)
+			}
+			if _, ok := data["code_verifier"]; ok != (test.method != "") {
+				t.Fatalf("data code_verifier present = %v", ok)
+			}
+			if test.method == "" && query.Get("code_challenge") != "" {
+				t.Fatal("code_challenge sent with PKCE off")
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package oauth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type (
+	// PrometheusCollector is an Instrument exposing request counts and latency histograms in the Prometheus text format
+	PrometheusCollector struct {
+		Namespace string
+		Buckets   []float64
+
+		mu        sync.Mutex
+		requests  map[requestLabels]uint64
+		durations map[durationLabels]*histogram
+	}
+
+	requestLabels struct {
+		provider  string
+		operation string
+		status    int
+		errorKind string
+	}
+
+	durationLabels struct {
+		provider  string
+		operation string
+	}
+
+	histogram struct {
+		counts []uint64
+		count  uint64
+		sum    float64
+	}
+)
+
+// PrometheusBuckets are the default latency buckets in seconds
+var PrometheusBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+func NewPrometheusCollector() *PrometheusCollector {
+	return &PrometheusCollector{
+		Namespace: "oauth_client",
+	}
+}
+
+func (c *PrometheusCollector) buckets() []float64 {
+	if c.Buckets == nil {
+		return PrometheusBuckets
+	}
+	return c.Buckets
+}
+
+func (c *PrometheusCollector) Observe(ctx context.Context, event *Event) {
+	seconds := event.Duration.Seconds()
+	buckets := c.buckets()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.requests == nil {
+		c.requests = map[requestLabels]uint64{}
+		c.durations = map[durationLabels]*histogram{}
+	}
+	c.requests[requestLabels{event.Provider, event.Operation, event.Status, event.ErrorKind}]++
+
+	key := durationLabels{event.Provider, event.Operation}
+	h, ok := c.durations[key]
+	if !ok {
+		h = &histogram{
+			counts: make([]uint64, len(buckets)),
+		}
+		c.durations[key] = h
+	}
+	for i, le := range buckets {
+		if seconds <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func (c *PrometheusCollector) WriteTo(w io.Writer) (n int64, err error) {
+	namespace := c.Namespace
+	if namespace == "" {
+		namespace = "oauth_client"
+	}
+	buckets := c.buckets()
+	b := &bytes.Buffer{}
+
+	c.mu.Lock()
+	requests := make([]requestLabels, 0, len(c.requests))
+	for labels := range c.requests {
+		requests = append(requests, labels)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		switch {
+		case a.provider != b.provider:
+			return a.provider < b.provider
+		case a.operation != b.operation:
+			return a.operation < b.operation
+		case a.status != b.status:
+			return a.status < b.status
+		}
+		return a.errorKind < b.errorKind
+	})
+	fmt.Fprintf(b, "# HELP %s_requests_total Requests sent to providers.\n", namespace)
+	fmt.Fprintf(b, "# TYPE %s_requests_total counter\n", namespace)
+	for _, labels := range requests {
+		fmt.Fprintf(b, "%s_requests_total{provider=%s,operation=%s,status=\"%d\",error_kind=%s} %d\n", namespace, quoteLabel(labels.provider), quoteLabel(labels.operation), labels.status, quoteLabel(labels.errorKind), c.requests[labels])
+	}
+
+	durations := make([]durationLabels, 0, len(c.durations))
+	for labels := range c.durations {
+		durations = append(durations, labels)
+	}
+	sort.Slice(durations, func(i, j int) bool {
+		if durations[i].provider != durations[j].provider {
+			return durations[i].provider < durations[j].provider
+		}
+		return durations[i].operation < durations[j].operation
+	})
+	name := namespace + "_request_duration_seconds"
+	fmt.Fprintf(b, "# HELP %s Latency of requests sent to providers.\n", name)
+	fmt.Fprintf(b, "# TYPE %s histogram\n", name)
+	for _, labels := range durations {
+		h := c.durations[labels]
+		l := "provider=" + quoteLabel(labels.provider) + ",operation=" + quoteLabel(labels.operation)
+		for i, le := range buckets {
+			fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, l, strconv.FormatFloat(le, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, l, h.count)
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", name, l, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", name, l, h.count)
+	}
+	c.mu.Unlock()
+
+	return b.WriteTo(w)
+}
+
+func (c *PrometheusCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+func quoteLabel(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, "\n", `\n`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return `"` + value + `"`
+}
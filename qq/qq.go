@@ -2,6 +2,7 @@ package qq
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/url"
 	"time"
@@ -13,6 +14,14 @@ type (
 	Client struct {
 		oauth.OAuth2
 	}
+
+	userResponse struct {
+		Ret          json.Number `json:"ret"`
+		Nickname     string      `json:"nickname"`
+		FigureURLQQ1 string      `json:"figureurl_qq_1"`
+		FigureURLQQ2 string      `json:"figureurl_qq_2"`
+		Gender       string      `json:"gender"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -23,6 +32,30 @@ var Endpoint = oauth.Endpoint{
 	APIURL:          "https://graph.qq.com",
 	ScopeSep:        ",",
 	Errors:          []string{"msg", "ret"},
+	// https://wiki.connect.qq.com/公共返回码说明
+	ErrorCodes: map[string]string{
+		"100013": "token_invalid",
+		"100014": "token_expired",
+		"100015": "consent_revoked",
+		"100016": "token_invalid",
+		"100019": "invalid_grant",
+		"100020": "invalid_grant",
+	},
+}
+
+func init() {
+	oauth.Register("qq", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
 }
 
 func (c *Client) Exchange(ctx context.Context, query url.Values, data map[string]interface{}, values url.Values) (token *oauth.Token, err error) {
@@ -59,14 +92,15 @@ func (c *Client) OpenID(ctx context.Context, token *oauth.Token) (err error) {
 		return
 	}
 
-	httpClient := oauth.HTTPClient(ctx, nil, nil)
+	// the access token is in the query, the client credentials must not be added
+	httpClient := oauth.UnsignedHTTPClient(ctx, c)
 	var raw map[string]interface{}
 	if raw, err = c.Response(ctx, httpClient, req); err != nil {
 		return
 	}
 	openid, ok := raw["openid"].(string)
 	if !ok || openid == "" {
-		err = oauth.NewError("openid not string", 500)
+		err = oauth.UnexpectedResponse(raw, "openid is missing or invalid")
 		return
 	}
 	token.OpenID = openid
@@ -90,6 +124,7 @@ func (c *Client) Signature(req *http.Request, token *oauth.Token, values url.Val
 }
 
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
 	var req *http.Request
 	if req, err = http.NewRequest("GET", c.Endpoint.APIURL+"/user/get_user_info", nil); err != nil {
@@ -97,33 +132,33 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 	}
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
+		return
+	}
+	// a non zero ret is an error, a body without ret is not a user
+	if res.Ret == "" {
+		err = oauth.UnexpectedResponse(raw, "ret is missing or invalid")
 		return
 	}
 
 	user = &oauth.User{
-		ID:      token.OpenID,
-		Raw:     raw,
-		Updated: &now,
-	}
-	if v, ok := raw["nickname"].(string); ok {
-		user.Nickname = v
-	}
-	if v, ok := raw["figureurl_qq_1"].(string); ok && v != "" {
-		user.Avatar = v
+		ID:       token.OpenID,
+		Nickname: res.Nickname,
+		Avatar:   res.FigureURLQQ1,
+		Raw:      raw,
+		Updated:  &now,
 	}
-	if v, ok := raw["figureurl_qq_2"].(string); ok && v != "" {
-		user.Avatar = v
+	if res.FigureURLQQ2 != "" {
+		user.Avatar = res.FigureURLQQ2
 	}
 
-	if v, ok := raw["gender"].(string); ok {
-		switch v {
-		case "男":
-			user.Gender = "male"
-		case "女":
-			user.Gender = "female"
-		}
+	switch res.Gender {
+	case "男":
+		user.Gender = "male"
+	case "女":
+		user.Gender = "female"
 	}
 	return
 }
@@ -0,0 +1,69 @@
+package qq
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token", OpenID: "openid"}, []providertest.Case{
+		{Name: "missing ret", Body: `{"nickname":"a"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "number ret", Body: `{"ret":0,"msg":"","nickname":"a"}`, ID: "openid"},
+		{Name: "string ret", Body: `{"ret":"0","nickname":"a"}`, ID: "openid"},
+		{Name: "wrong typed fields", Body: `{"ret":0,"nickname":5,"figureurl_qq_1":[],"figureurl_qq_2":"https://example.com/a","gender":{}}`, ID: "openid", Check: func(t *testing.T, user *oauth.User) {
+			if user.Nickname != "" || user.Avatar != "https://example.com/a" || user.Gender != "" {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "ret with status 200", Body: `{"ret":100016,"msg":"access token check failed"}`, Err: oauth.ErrTokenInvalid},
+		{Name: "expired ret with status 200", Body: `{"ret":100014,"msg":"access token expired"}`, Err: oauth.ErrTokenExpired},
+	})
+}
+
+func TestOpenID(t *testing.T) {
+	instrument := &recordInstrument{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth2.0/me" || r.URL.Query().Get("access_token") != "token" {
+			t.Errorf("request %s", r.URL)
+		}
+		if r.URL.Query().Get("client_secret") != "" || r.URL.Query().Get("client_id") != "" {
+			t.Errorf("client credentials sent to %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"client_id":"client","openid":"o1"}`))
+	}))
+	defer server.Close()
+	client := New(oauth.Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Endpoint:     oauth.Endpoint{APIURL: server.URL},
+		Instrument:   instrument,
+	})
+
+	token := &oauth.Token{AccessToken: "token"}
+	if err := client.OpenID(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+	if token.OpenID != "o1" {
+		t.Fatalf("OpenID = %q, want o1", token.OpenID)
+	}
+	// the request goes through the Instrument of the config
+	if len(instrument.events) != 1 || instrument.events[0].Provider != "qq" {
+		t.Fatalf("events = %+v", instrument.events)
+	}
+}
+
+type recordInstrument struct {
+	events []*oauth.Event
+}
+
+func (r *recordInstrument) Observe(ctx context.Context, event *oauth.Event) {
+	r.events = append(r.events, event)
+}
@@ -0,0 +1,131 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type (
+	// Refresher collapses concurrent refreshes of the same refresh token into a single request
+	Refresher struct {
+		Client Client
+		// Grace is how long a completed refresh is handed to callers still holding the old refresh token
+		Grace time.Duration
+		// OnRotate is called once when the provider issued a new refresh token
+		OnRotate func(ctx context.Context, oldToken *Token, newToken *Token)
+
+		mu    sync.Mutex
+		calls map[string]*refreshCall
+	}
+
+	refreshCall struct {
+		done    chan struct{}
+		token   *Token
+		err     error
+		expired time.Time
+	}
+)
+
+var ErrReauthenticate = newCodeError("reauthentication_required", 401)
+
+// RefreshGrace is the default Refresher.Grace
+var RefreshGrace = 30 * time.Second
+
+func NewRefresher(client Client) *Refresher {
+	return &Refresher{
+		Client: client,
+	}
+}
+
+func (r *Refresher) RefreshToken(ctx context.Context, oldToken *Token, values url.Values) (newToken *Token, err error) {
+	if oldToken.RefreshToken == "" {
+		err = NewError("Cannot refresh the token", 500)
+		return
+	}
+	sum := sha256.Sum256([]byte(oldToken.ClientID + "\n" + oldToken.RefreshToken))
+	key := hex.EncodeToString(sum[:])
+	now := time.Now()
+
+	r.mu.Lock()
+	if r.calls == nil {
+		r.calls = map[string]*refreshCall{}
+	}
+	for k, call := range r.calls {
+		if !call.expired.IsZero() && now.After(call.expired) {
+			delete(r.calls, k)
+		}
+	}
+	call, ok := r.calls[key]
+	if !ok {
+		call = &refreshCall{
+			done: make(chan struct{}),
+		}
+		r.calls[key] = call
+	}
+	r.mu.Unlock()
+
+	if ok {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		}
+		if err = call.err; err == nil {
+			newToken = call.token.Copy()
+		}
+		return
+	}
+
+	call.token, call.err = r.refresh(ctx, oldToken, values)
+
+	grace := r.Grace
+	if grace == 0 {
+		grace = RefreshGrace
+	}
+	r.mu.Lock()
+	if call.err != nil {
+		// let the next caller try again
+		delete(r.calls, key)
+	} else {
+		call.expired = time.Now().Add(grace)
+	}
+	r.mu.Unlock()
+	close(call.done)
+
+	if err = call.err; err == nil {
+		newToken = call.token.Copy()
+	}
+	return
+}
+
+func (r *Refresher) refresh(ctx context.Context, oldToken *Token, values url.Values) (newToken *Token, err error) {
+	if newToken, err = r.Client.RefreshToken(ctx, oldToken, values); err != nil {
+		if e, ok := err.(*Error); ok && e.Code == "invalid_grant" {
+			// keep the provider details, errors.Is matches ErrReauthenticate by code
+			err = &Error{
+				Message:     e.Message,
+				Status:      http.StatusUnauthorized,
+				Code:        "reauthentication_required",
+				Description: e.Description,
+				URI:         e.URI,
+				NativeCode:  e.NativeCode,
+				Header:      e.Header,
+				Attempts:    e.Attempts,
+			}
+		}
+		newToken = nil
+		return
+	}
+	if newToken.RefreshToken == "" {
+		newToken.RefreshToken = oldToken.RefreshToken
+	} else if newToken.RefreshToken != oldToken.RefreshToken && r.OnRotate != nil {
+		r.OnRotate(ctx, oldToken, newToken)
+	}
+	return
+}
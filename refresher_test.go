@@ -0,0 +1,133 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRefreshServer(t *testing.T, body string, status int, release chan struct{}) (*OAuth2, *int32) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		if release != nil {
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	client := &OAuth2{Config: Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Endpoint:     Endpoint{RefreshTokenURL: server.URL},
+	}}
+	return client, &count
+}
+
+func TestRefresherCoalesces(t *testing.T) {
+	release := make(chan struct{})
+	client, count := newRefreshServer(t, `{"access_token":"a2","token_type":"bearer","expires_in":3600,"refresh_token":"r2"}`, http.StatusOK, release)
+	var rotated int32
+	refresher := NewRefresher(client)
+	refresher.OnRotate = func(ctx context.Context, oldToken *Token, newToken *Token) {
+		atomic.AddInt32(&rotated, 1)
+		if oldToken.RefreshToken != "r1" || newToken.RefreshToken != "r2" {
+			t.Errorf("OnRotate(%s, %s)", oldToken.RefreshToken, newToken.RefreshToken)
+		}
+	}
+
+	var wg sync.WaitGroup
+	tokens := make([]*Token, 10)
+	errs := make([]error, len(tokens))
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens[i], errs[i] = refresher.RefreshToken(context.Background(), &Token{AccessToken: "a1", RefreshToken: "r1"}, nil)
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i, token := range tokens {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if token.AccessToken != "a2" || token.RefreshToken != "r2" {
+			t.Fatalf("token %d = %+v", i, token)
+		}
+	}
+	if n := atomic.LoadInt32(count); n != 1 {
+		t.Fatalf("%d token requests, want 1", n)
+	}
+	if n := atomic.LoadInt32(&rotated); n != 1 {
+		t.Fatalf("OnRotate called %d times, want 1", n)
+	}
+	// each caller gets its own copy
+	tokens[0].AccessToken = "changed"
+	if tokens[1].AccessToken != "a2" {
+		t.Fatal("callers share the refreshed token")
+	}
+}
+
+func TestRefresherGrace(t *testing.T) {
+	client, count := newRefreshServer(t, `{"access_token":"a2","token_type":"bearer","expires_in":3600}`, http.StatusOK, nil)
+	var rotated bool
+	refresher := &Refresher{
+		Client: client,
+		Grace:  50 * time.Millisecond,
+		OnRotate: func(ctx context.Context, oldToken *Token, newToken *Token) {
+			rotated = true
+		},
+	}
+	ctx := context.Background()
+	token, err := refresher.RefreshToken(ctx, &Token{AccessToken: "a1", RefreshToken: "r1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the provider did not rotate, the old refresh token is kept
+	if token.RefreshToken != "r1" || rotated {
+		t.Fatalf("RefreshToken = %q, rotated %v", token.RefreshToken, rotated)
+	}
+	if _, err = refresher.RefreshToken(ctx, &Token{AccessToken: "a1", RefreshToken: "r1"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(count); n != 1 {
+		t.Fatalf("%d token requests within the grace period, want 1", n)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, err = refresher.RefreshToken(ctx, &Token{AccessToken: "a1", RefreshToken: "r1"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(count); n != 2 {
+		t.Fatalf("%d token requests after the grace period, want 2", n)
+	}
+}
+
+func TestRefresherInvalidGrant(t *testing.T) {
+	client, count := newRefreshServer(t, `{"error":"invalid_grant","error_description":"refresh token revoked"}`, http.StatusBadRequest, nil)
+	refresher := NewRefresher(client)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := refresher.RefreshToken(ctx, &Token{AccessToken: "a1", RefreshToken: "r1"}, nil)
+		if !errors.Is(err, ErrReauthenticate) {
+			t.Fatalf("RefreshToken() error = %v, want ErrReauthenticate", err)
+		}
+		var e *Error
+		if !errors.As(err, &e) || e.Description != "refresh token revoked" || e.Status != http.StatusUnauthorized {
+			t.Fatalf("RefreshToken() error = %#v", err)
+		}
+	}
+	// failures are not cached
+	if n := atomic.LoadInt32(count); n != 2 {
+		t.Fatalf("%d token requests, want 2", n)
+	}
+}
@@ -0,0 +1,139 @@
+package oauth
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+type (
+	Factory func(config Config) Client
+
+	Provider struct {
+		Name         string   `json:"name"`
+		Version      string   `json:"version"`
+		Capabilities []string `json:"capabilities"`
+		Endpoint     Endpoint `json:"endpoint"`
+	}
+
+	configurer interface {
+		config() *Config
+	}
+)
+
+var registry = struct {
+	sync.RWMutex
+	factories map[string]Factory
+}{
+	factories: map[string]Factory{},
+}
+
+func (c *Config) config() *Config {
+	return c
+}
+
+// Register makes a provider available by name, provider packages call it in init. It panics if name is registered twice
+func Register(name string, factory Factory) {
+	registry.Lock()
+	defer registry.Unlock()
+	if factory == nil {
+		panic("oauth: Register factory is nil")
+	}
+	if _, ok := registry.factories[name]; ok {
+		panic("oauth: Register called twice for provider " + name)
+	}
+	registry.factories[name] = factory
+}
+
+func NewClient(name string, config Config) (client Client, err error) {
+	registry.RLock()
+	factory, ok := registry.factories[name]
+	registry.RUnlock()
+	if !ok {
+		err = NewError("oauth provider does not exist: "+name, 500)
+		return
+	}
+	client = factory(config)
+	return
+}
+
+func LookupProvider(name string) (provider *Provider, ok bool) {
+	registry.RLock()
+	factory, ok := registry.factories[name]
+	registry.RUnlock()
+	if !ok {
+		return
+	}
+	provider = newProvider(name, factory(Config{}))
+	return
+}
+
+// Providers lists the registered providers sorted by name
+func Providers() (providers []*Provider) {
+	registry.RLock()
+	names := make([]string, 0, len(registry.factories))
+	for name := range registry.factories {
+		names = append(names, name)
+	}
+	registry.RUnlock()
+	sort.Strings(names)
+
+	providers = make([]*Provider, 0, len(names))
+	for _, name := range names {
+		if provider, ok := LookupProvider(name); ok {
+			providers = append(providers, provider)
+		}
+	}
+	return
+}
+
+func newProvider(name string, client Client) (provider *Provider) {
+	provider = &Provider{
+		Name:         name,
+		Version:      client.Version(),
+		Capabilities: make([]string, 0),
+	}
+	c, ok := client.(configurer)
+	if !ok {
+		return
+	}
+	endpoint := c.config().Endpoint
+	provider.Endpoint = endpoint
+
+	if client.Version() == "1.0" {
+		provider.Capabilities = append(provider.Capabilities, "oauth1")
+		return
+	}
+	provider.Capabilities = append(provider.Capabilities, "authorization_code")
+	if endpoint.RefreshTokenURL != "" {
+		provider.Capabilities = append(provider.Capabilities, "refresh_token")
+	}
+	if endpoint.RevokeTokenURL != "" {
+		provider.Capabilities = append(provider.Capabilities, "revoke")
+	}
+	if endpoint.DeviceURL != "" {
+		provider.Capabilities = append(provider.Capabilities, "device_code")
+	}
+	if endpoint.IntrospectURL != "" {
+		provider.Capabilities = append(provider.Capabilities, "introspection")
+	}
+	if endpoint.PKCEMethod() != "" {
+		provider.Capabilities = append(provider.Capabilities, "pkce")
+	}
+	if endpoint.JWKSURL != "" {
+		provider.Capabilities = append(provider.Capabilities, "openid")
+	}
+	return
+}
+
+// MergeEndpoint returns base with every non-zero field of override applied, set PKCE to PKCENone to turn off the PKCE of base
+func MergeEndpoint(base Endpoint, override Endpoint) Endpoint {
+	b := reflect.ValueOf(&base).Elem()
+	o := reflect.ValueOf(override)
+	for i := 0; i < o.NumField(); i++ {
+		if !o.Field(i).IsZero() {
+			b.Field(i).Set(o.Field(i))
+		}
+	}
+	return base
+}
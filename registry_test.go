@@ -0,0 +1,105 @@
+package oauth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeEndpoint(t *testing.T) {
+	base := Endpoint{
+		Name:           "example",
+		AuthorizeURL:   "https://example.com/authorize",
+		AccessTokenURL: "https://example.com/token",
+		Scopes:         []string{"openid", "email"},
+		PKCE:           PKCES256,
+		TokenHeader:    "Bearer",
+		ErrorCodes:     map[string]string{"4": "rate_limited"},
+	}
+	tests := []struct {
+		name     string
+		override Endpoint
+		want     Endpoint
+	}{
+		{"empty override", Endpoint{}, base},
+		{
+			"string fields",
+			Endpoint{AccessTokenURL: "https://proxy.example.com/token", Issuer: "https://example.com"},
+			Endpoint{
+				Name:           "example",
+				AuthorizeURL:   "https://example.com/authorize",
+				AccessTokenURL: "https://proxy.example.com/token",
+				Issuer:         "https://example.com",
+				Scopes:         []string{"openid", "email"},
+				PKCE:           PKCES256,
+				TokenHeader:    "Bearer",
+				ErrorCodes:     map[string]string{"4": "rate_limited"},
+			},
+		},
+		{
+			// slices and maps replace the base, they are not merged
+			"slices and maps",
+			Endpoint{Scopes: []string{"profile"}, ErrorCodes: map[string]string{"190": "token_invalid"}},
+			Endpoint{
+				Name:           "example",
+				AuthorizeURL:   "https://example.com/authorize",
+				AccessTokenURL: "https://example.com/token",
+				Scopes:         []string{"profile"},
+				PKCE:           PKCES256,
+				TokenHeader:    "Bearer",
+				ErrorCodes:     map[string]string{"190": "token_invalid"},
+			},
+		},
+		{
+			// an empty list set explicitly is not zero, it clears the base
+			"empty slice",
+			Endpoint{Scopes: []string{}},
+			Endpoint{
+				Name:           "example",
+				AuthorizeURL:   "https://example.com/authorize",
+				AccessTokenURL: "https://example.com/token",
+				Scopes:         []string{},
+				PKCE:           PKCES256,
+				TokenHeader:    "Bearer",
+				ErrorCodes:     map[string]string{"4": "rate_limited"},
+			},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if got := MergeEndpoint(base, test.override); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("MergeEndpoint() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+	if base.AccessTokenURL != "https://example.com/token" || len(base.Scopes) != 2 {
+		t.Fatalf("MergeEndpoint() modified base: %+v", base)
+	}
+}
+
+// TestMergeEndpointFields fails when a new Endpoint field cannot be overridden
+func TestMergeEndpointFields(t *testing.T) {
+	var override Endpoint
+	o := reflect.ValueOf(&override).Elem()
+	for i := 0; i < o.NumField(); i++ {
+		field := o.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(o.Type().Field(i).Name)
+		case reflect.Slice:
+			field.Set(reflect.ValueOf([]string{o.Type().Field(i).Name}))
+		case reflect.Map:
+			field.Set(reflect.ValueOf(map[string]string{"code": o.Type().Field(i).Name}))
+		default:
+			t.Fatalf("Endpoint.%s has an untested kind %s", o.Type().Field(i).Name, field.Kind())
+		}
+	}
+	base := Endpoint{
+		Name:       "example",
+		Scopes:     []string{"openid"},
+		ErrorCodes: map[string]string{"4": "rate_limited"},
+	}
+	if got := MergeEndpoint(base, override); !reflect.DeepEqual(got, override) {
+		t.Fatalf("MergeEndpoint() = %+v, want %+v", got, override)
+	}
+}
@@ -0,0 +1,158 @@
+package oauth
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// RetryPolicy retries failed requests of a Transport. Idempotent requests are retried on network errors and 429/5xx, other requests only on 429 and 503 or when they carry an Idempotency-Key header
+	RetryPolicy struct {
+		// MaxAttempts includes the first attempt
+		MaxAttempts int
+		MinBackoff  time.Duration
+		MaxBackoff  time.Duration
+		// MaxElapsed stops retrying when the next attempt would start later than this after the first
+		MaxElapsed time.Duration
+	}
+
+	attemptsKey struct{}
+)
+
+var RetryMaxAttempts = 3
+
+var RetryMinBackoff = 500 * time.Millisecond
+
+var RetryMaxBackoff = 30 * time.Second
+
+var RetryMaxElapsed = 2 * time.Minute
+
+func (p *RetryPolicy) do(req *http.Request, send func(req *http.Request) (*http.Response, error)) (res *http.Response, err error) {
+	ctx := req.Context()
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return send(req)
+	}
+	maxAttempts := p.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = RetryMaxAttempts
+	}
+	maxElapsed := p.MaxElapsed
+	if maxElapsed == 0 {
+		maxElapsed = RetryMaxElapsed
+	}
+
+	// send signs and modifies the request, later attempts start from a copy
+	orig := req.Clone(ctx)
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			req = orig.Clone(ctx)
+			if orig.GetBody != nil {
+				if req.Body, err = orig.GetBody(); err != nil {
+					return
+				}
+			}
+		}
+		res, err = send(req)
+		if attempt >= maxAttempts || ctx.Err() != nil || !p.retryable(orig, res, err) {
+			return
+		}
+
+		wait := p.backoff(attempt)
+		if res != nil {
+			if after, ok := retryAfter(res.Header.Get("Retry-After")); ok {
+				wait = after
+			}
+		}
+		if time.Since(start)+wait > maxElapsed {
+			return
+		}
+		if res != nil {
+			io.Copy(ioutil.Discard, io.LimitReader(res.Body, 4096))
+			res.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			res = nil
+			err = ctx.Err()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (p *RetryPolicy) retryable(req *http.Request, res *http.Response, err error) bool {
+	idempotent := req.Header.Get("Idempotency-Key") != ""
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		idempotent = true
+	}
+	if err != nil {
+		return idempotent
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		// the request was not processed
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// backoff is exponential with equal jitter
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	min := p.MinBackoff
+	if min == 0 {
+		min = RetryMinBackoff
+	}
+	max := p.MaxBackoff
+	if max == 0 {
+		max = RetryMaxBackoff
+	}
+	d := min
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func retryAfter(value string) (wait time.Duration, ok bool) {
+	if value == "" {
+		return
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if wait = time.Until(t); wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return
+}
+
+// withAttempts returns a context counting the requests a Transport sends with it
+func withAttempts(ctx context.Context) (context.Context, *int32) {
+	attempts := new(int32)
+	return context.WithValue(ctx, attemptsKey{}, attempts), attempts
+}
+
+func countAttempt(ctx context.Context) {
+	if attempts, ok := ctx.Value(attemptsKey{}).(*int32); ok {
+		atomic.AddInt32(attempts, 1)
+	}
+}
@@ -0,0 +1,132 @@
+package oauth
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+		ok    bool
+	}{
+		{"", 0, 0, false},
+		{"120", 120 * time.Second, 120 * time.Second, true},
+		{"0", 0, 0, true},
+		{"-1", 0, 0, false},
+		{"soon", 0, 0, false},
+		{time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat), 28 * time.Second, 30 * time.Second, true},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0, true},
+	}
+	for _, test := range tests {
+		wait, ok := retryAfter(test.value)
+		if ok != test.ok || wait < test.min || wait > test.max {
+			t.Errorf("retryAfter(%q) = %v, %v", test.value, wait, ok)
+		}
+	}
+}
+
+// retryServer answers with statuses in order, the last one repeats
+type retryServer struct {
+	mu       sync.Mutex
+	statuses []int
+	header   http.Header
+	bodies   []string
+}
+
+func (s *retryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, _ := ioutil.ReadAll(r.Body)
+	s.mu.Lock()
+	status := s.statuses[0]
+	if len(s.statuses) > 1 {
+		s.statuses = s.statuses[1:]
+	}
+	s.bodies = append(s.bodies, string(b))
+	s.mu.Unlock()
+	for k, v := range s.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(status)
+}
+
+func (s *retryServer) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+type onlyReader struct{ io.Reader }
+
+func TestRetryPolicy(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	tests := []struct {
+		name     string
+		policy   *RetryPolicy
+		method   string
+		header   http.Header
+		body     func() io.Reader
+		statuses []int
+		reply    http.Header
+		attempts int
+		status   int
+	}{
+		{"GET on 500", policy, "GET", nil, nil, []int{500, 500, 200}, nil, 3, 200},
+		{"GET gives up after MaxAttempts", policy, "GET", nil, nil, []int{502}, nil, 3, 502},
+		{"GET not on 400", policy, "GET", nil, nil, []int{400, 200}, nil, 1, 400},
+		{"POST not on 500", policy, "POST", nil, func() io.Reader { return strings.NewReader("a=b") }, []int{500, 200}, nil, 1, 500},
+		{"POST on 503", policy, "POST", nil, func() io.Reader { return strings.NewReader("a=b") }, []int{503, 200}, nil, 2, 200},
+		{"POST on 429", policy, "POST", nil, func() io.Reader { return strings.NewReader("a=b") }, []int{429, 200}, nil, 2, 200},
+		{"POST with Idempotency-Key on 500", policy, "POST", http.Header{"Idempotency-Key": {"k"}}, func() io.Reader { return strings.NewReader("a=b") }, []int{500, 200}, nil, 2, 200},
+		{"POST body without GetBody", policy, "POST", nil, func() io.Reader { return onlyReader{strings.NewReader("a=b")} }, []int{503, 200}, nil, 1, 503},
+		{"Retry-After seconds", policy, "GET", nil, nil, []int{429, 200}, http.Header{"Retry-After": {"0"}}, 2, 200},
+		{"Retry-After HTTP-date", policy, "GET", nil, nil, []int{503, 200}, http.Header{"Retry-After": {time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)}}, 2, 200},
+		{"Retry-After beyond MaxElapsed", &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxElapsed: time.Second}, "GET", nil, nil, []int{503, 200}, http.Header{"Retry-After": {"3600"}}, 1, 503},
+		{"backoff beyond MaxElapsed", &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Hour, MaxElapsed: time.Second}, "GET", nil, nil, []int{503, 200}, nil, 1, 503},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &retryServer{statuses: test.statuses, header: test.reply}
+			server := httptest.NewServer(s)
+			defer server.Close()
+
+			var body io.Reader
+			if test.body != nil {
+				body = test.body()
+			}
+			req, err := http.NewRequest(test.method, server.URL, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range test.header {
+				req.Header[k] = v
+			}
+			start := time.Now()
+			res, err := (&http.Client{Transport: &Transport{Retry: test.policy}}).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("took %v", time.Since(start))
+			}
+			if res.StatusCode != test.status || s.attempts() != test.attempts {
+				t.Fatalf("status %d after %d attempts, want %d after %d", res.StatusCode, s.attempts(), test.status, test.attempts)
+			}
+			if test.body != nil {
+				for i, b := range s.bodies {
+					if b != "a=b" {
+						t.Fatalf("attempt %d sent body %q", i+1, b)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package oauth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+type (
+	// Sealer encrypts with AES-GCM into "v1.<key id>.<base64url nonce+ciphertext>"
+	Sealer struct {
+		// Keys maps key ids to 16, 24 or 32 byte AES keys, old keys are kept to open existing envelopes
+		Keys map[string][]byte
+		// KeyID selects the key used to seal
+		KeyID string
+	}
+)
+
+const sealVersion = "v1"
+
+func NewSealer(keyID string, key []byte) *Sealer {
+	return &Sealer{
+		Keys:  map[string][]byte{keyID: key},
+		KeyID: keyID,
+	}
+}
+
+func (s *Sealer) aead(keyID string) (aead cipher.AEAD, err error) {
+	key, ok := s.Keys[keyID]
+	if !ok {
+		err = NewError("seal: key not found: "+keyID, 500)
+		return
+	}
+	var block cipher.Block
+	if block, err = aes.NewCipher(key); err != nil {
+		return
+	}
+	aead, err = cipher.NewGCM(block)
+	return
+}
+
+func (s *Sealer) Seal(plaintext []byte) (sealed string, err error) {
+	if s.KeyID == "" || strings.Contains(s.KeyID, ".") {
+		err = NewError("seal: invalid key id", 500)
+		return
+	}
+	var aead cipher.AEAD
+	if aead, err = s.aead(s.KeyID); err != nil {
+		return
+	}
+	header := sealVersion + "." + s.KeyID
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return
+	}
+	b := aead.Seal(nonce, nonce, plaintext, []byte(header))
+	sealed = header + "." + base64.RawURLEncoding.EncodeToString(b)
+	return
+}
+
+func (s *Sealer) Open(sealed string) (plaintext []byte, err error) {
+	parts := strings.Split(sealed, ".")
+	if len(parts) != 3 || parts[0] != sealVersion {
+		err = NewError("seal: malformed envelope", 500)
+		return
+	}
+	var aead cipher.AEAD
+	if aead, err = s.aead(parts[1]); err != nil {
+		return
+	}
+	var b []byte
+	if b, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		err = NewError("seal: malformed envelope", 500)
+		return
+	}
+	if len(b) < aead.NonceSize() {
+		err = NewError("seal: malformed envelope", 500)
+		return
+	}
+	if plaintext, err = aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], []byte(parts[0]+"."+parts[1])); err != nil {
+		err = NewError("seal: cannot open envelope", 500)
+	}
+	return
+}
+
+// SealedKeyID returns the key id of an envelope
+func SealedKeyID(sealed string) string {
+	parts := strings.Split(sealed, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	return parts[1]
+}
+
+// Reseal re-encrypts an envelope under the current KeyID, envelopes already using it are returned as is
+func (s *Sealer) Reseal(sealed string) (resealed string, err error) {
+	if SealedKeyID(sealed) == s.KeyID {
+		if _, err = s.Open(sealed); err == nil {
+			resealed = sealed
+		}
+		return
+	}
+	var plaintext []byte
+	if plaintext, err = s.Open(sealed); err != nil {
+		return
+	}
+	resealed, err = s.Seal(plaintext)
+	return
+}
+
+func (s *Sealer) SealToken(token *Token) (sealed string, err error) {
+	var b []byte
+	if b, err = json.Marshal(token); err != nil {
+		return
+	}
+	sealed, err = s.Seal(b)
+	return
+}
+
+func (s *Sealer) OpenToken(sealed string) (token *Token, err error) {
+	var b []byte
+	if b, err = s.Open(sealed); err != nil {
+		return
+	}
+	token = &Token{}
+	if err = json.Unmarshal(b, token); err != nil {
+		token = nil
+	}
+	return
+}
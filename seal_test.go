@@ -0,0 +1,113 @@
+package oauth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestSealerRoundTrip(t *testing.T) {
+	sealer := NewSealer("k1", bytes.Repeat([]byte{1}, 32))
+	sealed, err := sealer.SealToken(&Token{AccessToken: "access", RefreshToken: "refresh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(sealed, ".")
+	if len(parts) != 3 || parts[0] != "v1" || parts[1] != "k1" || SealedKeyID(sealed) != "k1" {
+		t.Fatalf("envelope %q is not v1.k1.<payload>", sealed)
+	}
+	if strings.Contains(sealed, "access") {
+		t.Fatal("envelope contains the plaintext")
+	}
+	token, err := sealer.OpenToken(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "access" || token.RefreshToken != "refresh" {
+		t.Fatalf("OpenToken() = %+v", token)
+	}
+	// a fresh nonce every time
+	if again, _ := sealer.SealToken(&Token{AccessToken: "access", RefreshToken: "refresh"}); again == sealed {
+		t.Fatal("sealing twice gives the same envelope")
+	}
+}
+
+func TestSealerOpenErrors(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	sealer := &Sealer{Keys: map[string][]byte{"k1": key, "k2": key}, KeyID: "k1"}
+	sealed, err := sealer.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := strings.SplitN(sealed, ".", 3)[2]
+	b, _ := base64.RawURLEncoding.DecodeString(payload)
+	b[len(b)-1] ^= 1
+	tampered := "v1.k1." + base64.RawURLEncoding.EncodeToString(b)
+
+	tests := []struct {
+		name   string
+		sealer *Sealer
+		sealed string
+		err    string
+	}{
+		// same key under another id, the header is authenticated data
+		{"key id swapped", sealer, "v1.k2." + payload, "cannot open envelope"},
+		{"version changed", sealer, "v2.k1." + payload, "malformed envelope"},
+		{"wrong key", NewSealer("k1", bytes.Repeat([]byte{2}, 32)), sealed, "cannot open envelope"},
+		{"unknown key id", NewSealer("k3", key), sealed, "key not found: k1"},
+		{"tampered payload", sealer, tampered, "cannot open envelope"},
+		{"short payload", sealer, "v1.k1.AAAA", "malformed envelope"},
+		{"not base64", sealer, "v1.k1.!!!", "malformed envelope"},
+		{"not an envelope", sealer, `{"access_token":"a"}`, "malformed envelope"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.sealer.Open(test.sealed); err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Open() error = %v, want %q", err, test.err)
+			}
+		})
+	}
+
+	if _, err = (&Sealer{Keys: map[string][]byte{"a.b": key}, KeyID: "a.b"}).Seal([]byte("x")); err == nil {
+		t.Fatal("Seal() with a dotted key id succeeded")
+	}
+}
+
+func TestSealerReseal(t *testing.T) {
+	old := NewSealer("k1", bytes.Repeat([]byte{1}, 32))
+	sealed, err := old.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rotated: k2 seals, k1 is kept to open existing envelopes
+	rotated := &Sealer{Keys: map[string][]byte{"k1": old.Keys["k1"], "k2": bytes.Repeat([]byte{2}, 32)}, KeyID: "k2"}
+	resealed, err := rotated.Reseal(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if SealedKeyID(resealed) != "k2" {
+		t.Fatalf("Reseal() key id = %q, want k2", SealedKeyID(resealed))
+	}
+	if plaintext, err := rotated.Open(resealed); err != nil || string(plaintext) != "secret" {
+		t.Fatalf("Open() = %q, %v", plaintext, err)
+	}
+	if _, err = old.Open(resealed); err == nil {
+		t.Fatal("the old sealer opens an envelope of the new key")
+	}
+
+	again, err := rotated.Reseal(resealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != resealed {
+		t.Fatal("Reseal() of a current envelope changed it")
+	}
+	if _, err = rotated.Reseal("v1.k2." + strings.SplitN(sealed, ".", 3)[2]); err == nil {
+		t.Fatal("Reseal() accepted a corrupt envelope of the current key")
+	}
+	if _, err = NewSealer("k2", rotated.Keys["k2"]).Reseal(sealed); err == nil {
+		t.Fatal("Reseal() without the old key succeeded")
+	}
+}
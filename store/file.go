@@ -0,0 +1,221 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/otamoe/oauth-client"
+)
+
+type (
+	// File keeps all tokens in a single JSON file, rewritten atomically on every change
+	File struct {
+		Path string
+		Perm os.FileMode
+		// Sealer encrypts tokens at rest when set
+		Sealer *oauth.Sealer
+
+		mu sync.Mutex
+	}
+
+	fileEntry struct {
+		Provider string       `json:"provider"`
+		UserID   string       `json:"user_id"`
+		Token    *oauth.Token `json:"token,omitempty"`
+		Sealed   string       `json:"sealed,omitempty"`
+	}
+)
+
+func NewFile(path string) *File {
+	return &File{
+		Path: path,
+		Perm: 0600,
+	}
+}
+
+func (s *File) load() (entries []*oauth.TokenEntry, err error) {
+	var b []byte
+	if b, err = ioutil.ReadFile(s.Path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+			entries = make([]*oauth.TokenEntry, 0)
+		}
+		return
+	}
+	entries = make([]*oauth.TokenEntry, 0)
+	if len(b) == 0 {
+		return
+	}
+	var fileEntries []*fileEntry
+	if err = json.Unmarshal(b, &fileEntries); err != nil {
+		return
+	}
+	for _, fileEntry := range fileEntries {
+		entry := &oauth.TokenEntry{
+			Provider: fileEntry.Provider,
+			UserID:   fileEntry.UserID,
+			Token:    fileEntry.Token,
+		}
+		if fileEntry.Sealed != "" {
+			if s.Sealer == nil {
+				err = oauth.NewError("token is sealed but File.Sealer is nil", 500)
+				return
+			}
+			if entry.Token, err = s.Sealer.OpenToken(fileEntry.Sealed); err != nil {
+				return
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return
+}
+
+func (s *File) save(entries []*oauth.TokenEntry) (err error) {
+	sortEntries(entries)
+	fileEntries := make([]*fileEntry, 0, len(entries))
+	for _, entry := range entries {
+		fileEntry := &fileEntry{
+			Provider: entry.Provider,
+			UserID:   entry.UserID,
+			Token:    entry.Token,
+		}
+		if s.Sealer != nil {
+			if fileEntry.Sealed, err = s.Sealer.SealToken(entry.Token); err != nil {
+				return
+			}
+			fileEntry.Token = nil
+		}
+		fileEntries = append(fileEntries, fileEntry)
+	}
+	var b []byte
+	if b, err = json.MarshalIndent(fileEntries, "", "  "); err != nil {
+		return
+	}
+	perm := s.Perm
+	if perm == 0 {
+		perm = 0600
+	}
+	var f *os.File
+	if f, err = ioutil.TempFile(filepath.Dir(s.Path), "."+filepath.Base(s.Path)+".*"); err != nil {
+		return
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(b); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Chmod(perm); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	err = os.Rename(f.Name(), s.Path)
+	return
+}
+
+// Reseal rewrites the file, sealing every token under the current Sealer.KeyID
+func (s *File) Reseal(ctx context.Context) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []*oauth.TokenEntry
+	if entries, err = s.load(); err != nil {
+		return
+	}
+	err = s.save(entries)
+	return
+}
+
+func (s *File) Get(ctx context.Context, provider string, userID string) (token *oauth.Token, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []*oauth.TokenEntry
+	if entries, err = s.load(); err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.Provider == provider && entry.UserID == userID {
+			token = entry.Token
+			return
+		}
+	}
+	err = oauth.ErrTokenNotFound
+	return
+}
+
+func (s *File) Put(ctx context.Context, provider string, userID string, token *oauth.Token, updated *time.Time) (err error) {
+	if err = validate(provider, userID, token); err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []*oauth.TokenEntry
+	if entries, err = s.load(); err != nil {
+		return
+	}
+	index := -1
+	var stored *oauth.Token
+	for i, entry := range entries {
+		if entry.Provider == provider && entry.UserID == userID {
+			index = i
+			stored = entry.Token
+			break
+		}
+	}
+	if !oauth.TokenUpdated(stored, updated) {
+		err = oauth.ErrTokenConflict
+		return
+	}
+	entry := &oauth.TokenEntry{
+		Provider: provider,
+		UserID:   userID,
+		Token:    token,
+	}
+	if index == -1 {
+		entries = append(entries, entry)
+	} else {
+		entries[index] = entry
+	}
+	err = s.save(entries)
+	return
+}
+
+func (s *File) Delete(ctx context.Context, provider string, userID string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []*oauth.TokenEntry
+	if entries, err = s.load(); err != nil {
+		return
+	}
+	for i, entry := range entries {
+		if entry.Provider == provider && entry.UserID == userID {
+			entries = append(entries[:i], entries[i+1:]...)
+			err = s.save(entries)
+			return
+		}
+	}
+	err = oauth.ErrTokenNotFound
+	return
+}
+
+func (s *File) List(ctx context.Context, provider string, userID string) (entries []*oauth.TokenEntry, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var all []*oauth.TokenEntry
+	if all, err = s.load(); err != nil {
+		return
+	}
+	entries = make([]*oauth.TokenEntry, 0)
+	for _, entry := range all {
+		if match(entry, provider, userID) {
+			entries = append(entries, entry)
+		}
+	}
+	return
+}
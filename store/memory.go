@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/otamoe/oauth-client"
+)
+
+type (
+	Memory struct {
+		mu      sync.RWMutex
+		entries map[string]*oauth.TokenEntry
+	}
+)
+
+func NewMemory() *Memory {
+	return &Memory{
+		entries: map[string]*oauth.TokenEntry{},
+	}
+}
+
+func (s *Memory) Get(ctx context.Context, provider string, userID string) (token *oauth.Token, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.entries[key(provider, userID)]
+	if !ok {
+		err = oauth.ErrTokenNotFound
+		return
+	}
+	token = entry.Token.Copy()
+	return
+}
+
+func (s *Memory) Put(ctx context.Context, provider string, userID string, token *oauth.Token, updated *time.Time) (err error) {
+	if err = validate(provider, userID, token); err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = map[string]*oauth.TokenEntry{}
+	}
+	k := key(provider, userID)
+	var stored *oauth.Token
+	if entry, ok := s.entries[k]; ok {
+		stored = entry.Token
+	}
+	if !oauth.TokenUpdated(stored, updated) {
+		err = oauth.ErrTokenConflict
+		return
+	}
+	s.entries[k] = &oauth.TokenEntry{
+		Provider: provider,
+		UserID:   userID,
+		Token:    token.Copy(),
+	}
+	return
+}
+
+func (s *Memory) Delete(ctx context.Context, provider string, userID string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := key(provider, userID)
+	if _, ok := s.entries[k]; !ok {
+		err = oauth.ErrTokenNotFound
+		return
+	}
+	delete(s.entries, k)
+	return
+}
+
+func (s *Memory) List(ctx context.Context, provider string, userID string) (entries []*oauth.TokenEntry, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entries = make([]*oauth.TokenEntry, 0)
+	for _, entry := range s.entries {
+		if match(entry, provider, userID) {
+			entries = append(entries, &oauth.TokenEntry{
+				Provider: entry.Provider,
+				UserID:   entry.UserID,
+				Token:    entry.Token.Copy(),
+			})
+		}
+	}
+	sortEntries(entries)
+	return
+}
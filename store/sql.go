@@ -0,0 +1,279 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/otamoe/oauth-client"
+)
+
+type (
+	// SQL stores tokens in a table created by Schema, one row per provider and user
+	SQL struct {
+		DB    *sql.DB
+		Table string
+		// Dollar uses $1, $2 placeholders (postgres) instead of ?
+		Dollar bool
+		// Sealer encrypts tokens at rest when set
+		Sealer *oauth.Sealer
+	}
+)
+
+func NewSQL(db *sql.DB, table string) *SQL {
+	return &SQL{
+		DB:    db,
+		Table: table,
+	}
+}
+
+func (s *SQL) table() string {
+	if s.Table == "" {
+		return "oauth_tokens"
+	}
+	return s.Table
+}
+
+// query rewrites ? placeholders
+func (s *SQL) query(query string) string {
+	query = strings.Replace(query, "{table}", s.table(), -1)
+	if !s.Dollar {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Schema is a portable CREATE TABLE statement, updated holds Token.Updated in unix nanoseconds
+func (s *SQL) Schema() string {
+	return s.query(`CREATE TABLE IF NOT EXISTS {table} (
+	provider VARCHAR(64) NOT NULL,
+	user_id VARCHAR(255) NOT NULL,
+	token TEXT NOT NULL,
+	updated BIGINT NOT NULL,
+	PRIMARY KEY (provider, user_id)
+)`)
+}
+
+func (s *SQL) CreateTable(ctx context.Context) (err error) {
+	_, err = s.DB.ExecContext(ctx, s.Schema())
+	return
+}
+
+func updatedNano(updated *time.Time) int64 {
+	if updated == nil {
+		return 0
+	}
+	return updated.UnixNano()
+}
+
+func (s *SQL) encode(token *oauth.Token) (b string, err error) {
+	if s.Sealer != nil {
+		b, err = s.Sealer.SealToken(token)
+		return
+	}
+	var v []byte
+	if v, err = json.Marshal(token); err != nil {
+		return
+	}
+	b = string(v)
+	return
+}
+
+func (s *SQL) decode(b string) (token *oauth.Token, err error) {
+	// sealed envelopes never start with {
+	if !strings.HasPrefix(b, "{") {
+		if s.Sealer == nil {
+			err = oauth.NewError("token is sealed but SQL.Sealer is nil", 500)
+			return
+		}
+		token, err = s.Sealer.OpenToken(b)
+		return
+	}
+	token = &oauth.Token{}
+	err = json.Unmarshal([]byte(b), token)
+	return
+}
+
+// Reseal re-encrypts every row not yet sealed under the current Sealer.KeyID
+func (s *SQL) Reseal(ctx context.Context) (err error) {
+	if s.Sealer == nil {
+		err = oauth.NewError("SQL.Sealer is nil", 500)
+		return
+	}
+	type row struct {
+		provider string
+		userID   string
+		token    string
+	}
+	var rows *sql.Rows
+	if rows, err = s.DB.QueryContext(ctx, s.query("SELECT provider, user_id, token FROM {table}")); err != nil {
+		return
+	}
+	olds := make([]*row, 0)
+	for rows.Next() {
+		r := &row{}
+		if err = rows.Scan(&r.provider, &r.userID, &r.token); err != nil {
+			rows.Close()
+			return
+		}
+		if oauth.SealedKeyID(r.token) != s.Sealer.KeyID {
+			olds = append(olds, r)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	for _, r := range olds {
+		var token *oauth.Token
+		if token, err = s.decode(r.token); err != nil {
+			return
+		}
+		var b string
+		if b, err = s.encode(token); err != nil {
+			return
+		}
+		// skip rows changed in the meantime
+		if _, err = s.DB.ExecContext(ctx, s.query("UPDATE {table} SET token = ? WHERE provider = ? AND user_id = ? AND token = ?"), b, r.provider, r.userID, r.token); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (s *SQL) Get(ctx context.Context, provider string, userID string) (token *oauth.Token, err error) {
+	var b string
+	if err = s.DB.QueryRowContext(ctx, s.query("SELECT token FROM {table} WHERE provider = ? AND user_id = ?"), provider, userID).Scan(&b); err != nil {
+		if err == sql.ErrNoRows {
+			err = oauth.ErrTokenNotFound
+		}
+		return
+	}
+	token, err = s.decode(b)
+	return
+}
+
+func (s *SQL) Put(ctx context.Context, provider string, userID string, token *oauth.Token, updated *time.Time) (err error) {
+	if err = validate(provider, userID, token); err != nil {
+		return
+	}
+	var b string
+	if b, err = s.encode(token); err != nil {
+		return
+	}
+
+	var inserting bool
+	if inserting, err = s.put(ctx, provider, userID, b, updatedNano(token.Updated), updated); err != nil && inserting {
+		// a concurrent Put inserted the row first and the insert hit the primary key, the transaction is gone so look outside it
+		var stored int64
+		if s.DB.QueryRowContext(ctx, s.query("SELECT updated FROM {table} WHERE provider = ? AND user_id = ?"), provider, userID).Scan(&stored) == nil {
+			err = oauth.ErrTokenConflict
+		}
+	}
+	return
+}
+
+func (s *SQL) put(ctx context.Context, provider string, userID string, b string, nano int64, updated *time.Time) (inserting bool, err error) {
+	var tx *sql.Tx
+	if tx, err = s.DB.BeginTx(ctx, nil); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var res sql.Result
+	if updated == nil {
+		res, err = tx.ExecContext(ctx, s.query("UPDATE {table} SET token = ?, updated = ? WHERE provider = ? AND user_id = ?"), b, nano, provider, userID)
+	} else {
+		res, err = tx.ExecContext(ctx, s.query("UPDATE {table} SET token = ?, updated = ? WHERE provider = ? AND user_id = ? AND updated = ?"), b, nano, provider, userID, updatedNano(updated))
+	}
+	if err != nil {
+		return
+	}
+	var rows int64
+	if rows, err = res.RowsAffected(); err != nil || rows != 0 {
+		return
+	}
+
+	// mysql reports 0 affected rows when nothing changed, so look at the row
+	var stored int64
+	err = tx.QueryRowContext(ctx, s.query("SELECT updated FROM {table} WHERE provider = ? AND user_id = ?"), provider, userID).Scan(&stored)
+	switch {
+	case err == sql.ErrNoRows:
+		if updated != nil {
+			err = oauth.ErrTokenConflict
+			return
+		}
+		inserting = true
+		_, err = tx.ExecContext(ctx, s.query("INSERT INTO {table} (provider, user_id, token, updated) VALUES (?, ?, ?, ?)"), provider, userID, b, nano)
+	case err != nil:
+	case updated != nil && stored != updatedNano(updated):
+		err = oauth.ErrTokenConflict
+	}
+	return
+}
+
+func (s *SQL) Delete(ctx context.Context, provider string, userID string) (err error) {
+	var res sql.Result
+	if res, err = s.DB.ExecContext(ctx, s.query("DELETE FROM {table} WHERE provider = ? AND user_id = ?"), provider, userID); err != nil {
+		return
+	}
+	var rows int64
+	if rows, err = res.RowsAffected(); err == nil && rows == 0 {
+		err = oauth.ErrTokenNotFound
+	}
+	return
+}
+
+func (s *SQL) List(ctx context.Context, provider string, userID string) (entries []*oauth.TokenEntry, err error) {
+	query := "SELECT provider, user_id, token FROM {table} WHERE 1 = 1"
+	args := make([]interface{}, 0, 2)
+	if provider != "" {
+		query += " AND provider = ?"
+		args = append(args, provider)
+	}
+	if userID != "" {
+		query += " AND user_id = ?"
+		args = append(args, userID)
+	}
+	query += " ORDER BY provider, user_id"
+
+	var rows *sql.Rows
+	if rows, err = s.DB.QueryContext(ctx, s.query(query), args...); err != nil {
+		return
+	}
+	defer rows.Close()
+	entries = make([]*oauth.TokenEntry, 0)
+	for rows.Next() {
+		var b string
+		entry := &oauth.TokenEntry{}
+		if err = rows.Scan(&entry.Provider, &entry.UserID, &b); err != nil {
+			return
+		}
+		if entry.Token, err = s.decode(b); err != nil {
+			return
+		}
+		entries = append(entries, entry)
+	}
+	err = rows.Err()
+	return
+}
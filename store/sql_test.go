@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/otamoe/oauth-client"
+)
+
+// raceDriver is a single row table where another writer inserts the row between the SELECT and the INSERT of Put
+type raceDriver struct {
+	mu     sync.Mutex
+	exists bool
+}
+
+type raceConn struct{ d *raceDriver }
+
+type raceStmt struct {
+	d     *raceDriver
+	query string
+}
+
+type raceRows struct {
+	updated int64
+	done    bool
+}
+
+func (d *raceDriver) Open(name string) (driver.Conn, error) { return &raceConn{d}, nil }
+
+func (c *raceConn) Prepare(query string) (driver.Stmt, error) { return &raceStmt{c.d, query}, nil }
+func (c *raceConn) Close() error                              { return nil }
+func (c *raceConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c *raceConn) Commit() error                             { return nil }
+func (c *raceConn) Rollback() error                           { return nil }
+
+func (s *raceStmt) Close() error  { return nil }
+func (s *raceStmt) NumInput() int { return -1 }
+
+func (s *raceStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if strings.HasPrefix(s.query, "INSERT") {
+		s.d.exists = true
+		return nil, errors.New("UNIQUE constraint failed: oauth_tokens.provider, oauth_tokens.user_id")
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (s *raceStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	return &raceRows{done: !s.d.exists, updated: 1}, nil
+}
+
+func (r *raceRows) Columns() []string { return []string{"updated"} }
+func (r *raceRows) Close() error      { return nil }
+
+func (r *raceRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.updated
+	return nil
+}
+
+func TestSQLPutInsertRace(t *testing.T) {
+	sql.Register("oauth_race", &raceDriver{})
+	db, err := sql.Open("oauth_race", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	token := &oauth.Token{AccessToken: "access", Updated: &now}
+	err = NewSQL(db, "").Put(context.Background(), "github", "1", token, nil)
+	if !errors.Is(err, oauth.ErrTokenConflict) {
+		t.Fatalf("Put() error = %v, want ErrTokenConflict", err)
+	}
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"sort"
+
+	"github.com/otamoe/oauth-client"
+)
+
+func key(provider string, userID string) string {
+	return provider + "\x00" + userID
+}
+
+func validate(provider string, userID string, token *oauth.Token) (err error) {
+	if provider == "" || userID == "" {
+		err = oauth.NewError("provider and user id are required", 500)
+		return
+	}
+	if token == nil {
+		err = oauth.NewError("token is nil", 500)
+		return
+	}
+	return
+}
+
+func match(entry *oauth.TokenEntry, provider string, userID string) bool {
+	if provider != "" && entry.Provider != provider {
+		return false
+	}
+	if userID != "" && entry.UserID != userID {
+		return false
+	}
+	return true
+}
+
+func sortEntries(entries []*oauth.TokenEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Provider != entries[j].Provider {
+			return entries[i].Provider < entries[j].Provider
+		}
+		return entries[i].UserID < entries[j].UserID
+	})
+}
@@ -0,0 +1,268 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/otamoe/oauth-client"
+)
+
+// tableDriver runs the statements of SQL against an in memory table
+type tableDriver struct {
+	mu   sync.Mutex
+	rows map[string]*tableRow
+}
+
+type tableRow struct {
+	provider string
+	userID   string
+	token    string
+	updated  int64
+}
+
+type tableConn struct{ d *tableDriver }
+
+type tableStmt struct {
+	d     *tableDriver
+	query string
+}
+
+type tableRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (d *tableDriver) Open(name string) (driver.Conn, error) { return &tableConn{d}, nil }
+
+func (c *tableConn) Prepare(query string) (driver.Stmt, error) { return &tableStmt{c.d, query}, nil }
+func (c *tableConn) Close() error                              { return nil }
+func (c *tableConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c *tableConn) Commit() error                             { return nil }
+func (c *tableConn) Rollback() error                           { return nil }
+
+func (s *tableStmt) Close() error  { return nil }
+func (s *tableStmt) NumInput() int { return -1 }
+
+func (s *tableStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	q := s.query
+	switch {
+	case strings.HasPrefix(q, "CREATE TABLE"):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(q, "INSERT INTO"):
+		k := key(args[0].(string), args[1].(string))
+		if _, ok := s.d.rows[k]; ok {
+			return nil, errors.New("UNIQUE constraint failed")
+		}
+		s.d.rows[k] = &tableRow{args[0].(string), args[1].(string), args[2].(string), args[3].(int64)}
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(q, "UPDATE") && strings.Contains(q, "updated = ? WHERE"):
+		row, ok := s.d.rows[key(args[2].(string), args[3].(string))]
+		if !ok || (len(args) == 5 && row.updated != args[4].(int64)) {
+			return driver.RowsAffected(0), nil
+		}
+		row.token, row.updated = args[0].(string), args[1].(int64)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(q, "UPDATE"):
+		row, ok := s.d.rows[key(args[1].(string), args[2].(string))]
+		if !ok || row.token != args[3].(string) {
+			return driver.RowsAffected(0), nil
+		}
+		row.token = args[0].(string)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(q, "DELETE"):
+		k := key(args[0].(string), args[1].(string))
+		if _, ok := s.d.rows[k]; !ok {
+			return driver.RowsAffected(0), nil
+		}
+		delete(s.d.rows, k)
+		return driver.RowsAffected(1), nil
+	}
+	return nil, fmt.Errorf("unexpected statement %q", q)
+}
+
+func (s *tableStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	q := s.query
+	switch {
+	case strings.HasPrefix(q, "SELECT updated"), strings.HasPrefix(q, "SELECT token"):
+		rows := &tableRows{columns: []string{"value"}}
+		if row, ok := s.d.rows[key(args[0].(string), args[1].(string))]; ok {
+			if strings.HasPrefix(q, "SELECT updated") {
+				rows.values = append(rows.values, []driver.Value{row.updated})
+			} else {
+				rows.values = append(rows.values, []driver.Value{row.token})
+			}
+		}
+		return rows, nil
+	case strings.HasPrefix(q, "SELECT provider, user_id, token"):
+		var provider, userID string
+		i := 0
+		if strings.Contains(q, "provider = ?") {
+			provider = args[i].(string)
+			i++
+		}
+		if strings.Contains(q, "user_id = ?") {
+			userID = args[i].(string)
+		}
+		rows := &tableRows{columns: []string{"provider", "user_id", "token"}}
+		for _, row := range s.d.rows {
+			if (provider == "" || row.provider == provider) && (userID == "" || row.userID == userID) {
+				rows.values = append(rows.values, []driver.Value{row.provider, row.userID, row.token})
+			}
+		}
+		sort.Slice(rows.values, func(i, j int) bool {
+			return key(rows.values[i][0].(string), rows.values[i][1].(string)) < key(rows.values[j][0].(string), rows.values[j][1].(string))
+		})
+		return rows, nil
+	}
+	return nil, fmt.Errorf("unexpected query %q", q)
+}
+
+func (r *tableRows) Columns() []string { return r.columns }
+func (r *tableRows) Close() error      { return nil }
+
+func (r *tableRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+var registerTable sync.Once
+
+func newTableStore(t *testing.T) *SQL {
+	registerTable.Do(func() {
+		sql.Register("oauth_table", &tableDriver{rows: map[string]*tableRow{}})
+	})
+	db, err := sql.Open("oauth_table", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s := NewSQL(db, "")
+	// the driver is shared, start from an empty table
+	entries, err := s.List(context.Background(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		s.Delete(context.Background(), entry.Provider, entry.UserID)
+	}
+	if err = s.CreateTable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestStores(t *testing.T) {
+	stores := []struct {
+		name string
+		new  func(t *testing.T) oauth.TokenStore
+	}{
+		{"memory", func(t *testing.T) oauth.TokenStore { return NewMemory() }},
+		{"file", func(t *testing.T) oauth.TokenStore { return NewFile(filepath.Join(t.TempDir(), "tokens.json")) }},
+		{"sql", func(t *testing.T) oauth.TokenStore { return newTableStore(t) }},
+	}
+	for _, s := range stores {
+		t.Run(s.name, func(t *testing.T) {
+			testStore(t, s.new(t))
+		})
+	}
+}
+
+func testStore(t *testing.T, store oauth.TokenStore) {
+	ctx := context.Background()
+	at := func(n int64) *time.Time {
+		v := time.Unix(1600000000, n).UTC()
+		return &v
+	}
+
+	if _, err := store.Get(ctx, "github", "1"); !errors.Is(err, oauth.ErrTokenNotFound) {
+		t.Fatalf("Get() of a missing token error = %v, want ErrTokenNotFound", err)
+	}
+	if err := store.Delete(ctx, "github", "1"); !errors.Is(err, oauth.ErrTokenNotFound) {
+		t.Fatalf("Delete() of a missing token error = %v, want ErrTokenNotFound", err)
+	}
+	if err := store.Put(ctx, "github", "1", &oauth.Token{AccessToken: "a0", Updated: at(1)}, at(0)); !errors.Is(err, oauth.ErrTokenConflict) {
+		t.Fatalf("Put() expecting a missing token error = %v, want ErrTokenConflict", err)
+	}
+	if err := store.Put(ctx, "", "1", &oauth.Token{AccessToken: "a0"}, nil); err == nil {
+		t.Fatal("Put() without provider succeeded")
+	}
+
+	if err := store.Put(ctx, "google", "1", &oauth.Token{AccessToken: "g1", Updated: at(1)}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "github", "1", &oauth.Token{AccessToken: "a1", Updated: at(1)}, nil); err != nil {
+		t.Fatal(err)
+	}
+	// overwrite without a compare
+	if err := store.Put(ctx, "github", "1", &oauth.Token{AccessToken: "a2", Updated: at(2)}, nil); err != nil {
+		t.Fatal(err)
+	}
+	expect := func(access string) {
+		t.Helper()
+		token, err := store.Get(ctx, "github", "1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.AccessToken != access {
+			t.Fatalf("Get().AccessToken = %q, want %q", token.AccessToken, access)
+		}
+	}
+	expect("a2")
+
+	// compare and swap on Updated
+	if err := store.Put(ctx, "github", "1", &oauth.Token{AccessToken: "a3", Updated: at(3)}, at(1)); !errors.Is(err, oauth.ErrTokenConflict) {
+		t.Fatalf("Put() with a stale updated error = %v, want ErrTokenConflict", err)
+	}
+	expect("a2")
+	if err := store.Put(ctx, "github", "1", &oauth.Token{AccessToken: "a3", Updated: at(3)}, at(2)); err != nil {
+		t.Fatal(err)
+	}
+	expect("a3")
+
+	// a changed token does not change the stored one
+	token, err := store.Get(ctx, "github", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token.AccessToken = "changed"
+	expect("a3")
+
+	entries, err := store.List(ctx, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Provider != "github" || entries[1].Provider != "google" {
+		t.Fatalf("List() = %v", entries)
+	}
+	if entries, err = store.List(ctx, "google", ""); err != nil || len(entries) != 1 || entries[0].Token.AccessToken != "g1" {
+		t.Fatalf("List(google) = %v, %v", entries, err)
+	}
+	if entries, err = store.List(ctx, "", "2"); err != nil || len(entries) != 0 {
+		t.Fatalf("List(user 2) = %v, %v", entries, err)
+	}
+
+	if err = store.Delete(ctx, "github", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Get(ctx, "github", "1"); !errors.Is(err, oauth.ErrTokenNotFound) {
+		t.Fatalf("Get() of a deleted token error = %v, want ErrTokenNotFound", err)
+	}
+}
@@ -29,6 +29,7 @@ type (
 		Avatar      string                 `json:"avatar,omitempty"`
 		Gender      string                 `json:"gender,omitempty"`
 		Locale      string                 `json:"locale,omitempty"`
+		Timezone    string                 `json:"timezone,omitempty"`
 		Description string                 `json:"description,omitempty"`
 		Link        string                 `json:"link,omitempty"`
 		Auths       []*Auth                `json:"auths,omitempty"`
@@ -0,0 +1,98 @@
+package oauth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	TokenSource interface {
+		Token(ctx context.Context) (token *Token, err error)
+	}
+
+	// RefreshTokenSource refreshes its token with Client.RefreshToken shortly before it expires
+	RefreshTokenSource struct {
+		Client Client
+		// Refresher is shared between sources of the same token so only one refresh is sent
+		Refresher *Refresher
+		Leeway    time.Duration
+		// OnRefresh is called after each refresh so the caller can persist the new token
+		OnRefresh func(ctx context.Context, oldToken *Token, newToken *Token)
+
+		mu    sync.Mutex
+		token *Token
+	}
+)
+
+// TokenLeeway is how long before expiry a token is refreshed
+var TokenLeeway = time.Minute
+
+func NewTokenSource(client Client, token *Token) *RefreshTokenSource {
+	return &RefreshTokenSource{
+		Client: client,
+		token:  token,
+	}
+}
+
+func (s *RefreshTokenSource) Token(ctx context.Context) (token *Token, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token = s.token
+	if token == nil {
+		err = ErrTokenInvalid
+		return
+	}
+	leeway := s.Leeway
+	if leeway == 0 {
+		leeway = TokenLeeway
+	}
+	if token.Expired == nil || time.Now().Add(leeway).Before(*token.Expired) {
+		return
+	}
+	if token.RefreshToken == "" {
+		if token.Expired.Before(time.Now()) {
+			err = ErrTokenExpired
+		}
+		return
+	}
+	token, err = s.refresh(ctx)
+	return
+}
+
+// Refresh forces a refresh after the API rejected oldToken, unless the token was already replaced since
+func (s *RefreshTokenSource) Refresh(ctx context.Context, oldToken *Token) (token *Token, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != oldToken {
+		token = s.token
+		return
+	}
+	if s.token == nil || s.token.RefreshToken == "" {
+		err = ErrTokenInvalid
+		return
+	}
+	token, err = s.refresh(ctx)
+	return
+}
+
+func (s *RefreshTokenSource) refresh(ctx context.Context) (token *Token, err error) {
+	oldToken := s.token
+	if s.Refresher != nil {
+		token, err = s.Refresher.RefreshToken(ctx, oldToken, nil)
+	} else {
+		token, err = s.Client.RefreshToken(ctx, oldToken, nil)
+	}
+	if err != nil {
+		token = nil
+		return
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = oldToken.RefreshToken
+	}
+	s.token = token
+	if s.OnRefresh != nil {
+		s.OnRefresh(ctx, oldToken, token)
+	}
+	return
+}
@@ -0,0 +1,111 @@
+package oauth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransportRefreshOn401(t *testing.T) {
+	tests := []struct {
+		name string
+		// valid is the access token the API accepts
+		valid     string
+		method    string
+		body      io.Reader
+		requests  int32
+		refreshes int32
+		status    int
+	}{
+		{"replayed once", "a2", "GET", nil, 2, 1, http.StatusOK},
+		{"body replayed", "a2", "POST", strings.NewReader("q=1"), 2, 1, http.StatusOK},
+		{"still 401 after refresh", "a3", "GET", nil, 2, 1, http.StatusUnauthorized},
+		{"body without GetBody", "a2", "POST", io.MultiReader(strings.NewReader("q=1")), 1, 0, http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			client, refreshes := newRefreshServer(t, `{"access_token":"a2","token_type":"bearer","expires_in":3600}`, http.StatusOK, nil)
+			client.Endpoint.TokenHeader = "Bearer"
+
+			var requests int32
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				if r.Method == "POST" {
+					if body, _ := io.ReadAll(r.Body); string(body) != "q=1" {
+						t.Errorf("request body = %q", body)
+					}
+				}
+				if r.Header.Get("Authorization") != "Bearer "+test.valid {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte("ok"))
+			}))
+			defer api.Close()
+
+			var rotated *Token
+			expired := time.Now().Add(time.Hour)
+			source := NewTokenSource(client, &Token{AccessToken: "a1", RefreshToken: "r1", Expired: &expired})
+			source.OnRefresh = func(ctx context.Context, oldToken *Token, newToken *Token) {
+				rotated = newToken
+			}
+			httpClient := &http.Client{Transport: &Transport{Client: client, Source: source}}
+
+			req, err := http.NewRequest(test.method, api.URL, test.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := httpClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != test.status {
+				t.Fatalf("status = %d, want %d", res.StatusCode, test.status)
+			}
+			if n := atomic.LoadInt32(&requests); n != test.requests {
+				t.Fatalf("%d API requests, want %d", n, test.requests)
+			}
+			if n := atomic.LoadInt32(refreshes); n != test.refreshes {
+				t.Fatalf("%d refreshes, want %d", n, test.refreshes)
+			}
+			if test.refreshes != 0 {
+				if rotated == nil || rotated.AccessToken != "a2" || rotated.RefreshToken != "r1" {
+					t.Fatalf("OnRefresh token = %+v", rotated)
+				}
+				// the next request uses the refreshed token without another refresh
+				if token, _ := source.Token(context.Background()); token != rotated {
+					t.Fatalf("Token() = %+v, want the refreshed token", token)
+				}
+			}
+		})
+	}
+}
+
+func TestRefreshTokenSourceRefreshReplaced(t *testing.T) {
+	client, refreshes := newRefreshServer(t, `{"access_token":"a2","token_type":"bearer","expires_in":3600}`, http.StatusOK, nil)
+	old := &Token{AccessToken: "a1", RefreshToken: "r1"}
+	source := NewTokenSource(client, old)
+
+	token, err := source.Refresh(context.Background(), old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a second 401 for the token that was already replaced does not refresh again
+	again, err := source.Refresh(context.Background(), old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != token {
+		t.Fatalf("Refresh() = %+v, want %+v", again, token)
+	}
+	if n := atomic.LoadInt32(refreshes); n != 1 {
+		t.Fatalf("%d refreshes, want 1", n)
+	}
+}
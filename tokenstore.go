@@ -0,0 +1,37 @@
+package oauth
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// TokenStore persists tokens by provider and user. Put with a non-nil updated only succeeds while the stored token's Updated still equals it
+	TokenStore interface {
+		Get(ctx context.Context, provider string, userID string) (token *Token, err error)
+		Put(ctx context.Context, provider string, userID string, token *Token, updated *time.Time) (err error)
+		Delete(ctx context.Context, provider string, userID string) (err error)
+		// List filters by provider and user, empty matches all
+		List(ctx context.Context, provider string, userID string) (entries []*TokenEntry, err error)
+	}
+
+	TokenEntry struct {
+		Provider string `json:"provider"`
+		UserID   string `json:"user_id"`
+		Token    *Token `json:"token"`
+	}
+)
+
+var ErrTokenNotFound = newCodeError("token_not_found", 404)
+var ErrTokenConflict = newCodeError("token_conflict", 409)
+
+// TokenUpdated reports whether a stored token with Updated stored matches the expected updated time
+func TokenUpdated(stored *Token, updated *time.Time) bool {
+	if updated == nil {
+		return true
+	}
+	if stored == nil || stored.Updated == nil {
+		return false
+	}
+	return stored.Updated.Equal(*updated)
+}
@@ -1,20 +1,75 @@
 package oauth
 
 import (
-	"fmt"
+	"context"
 	"net/http"
-	"net/http/httputil"
+	"time"
 )
 
 type Transport struct {
 	Client Client
 	Token  *Token
-	Parent http.RoundTripper
+	// Source replaces Token when set, a 401 response is retried once after a forced refresh
+	Source TokenSource
+	// Unsigned sends requests without client or token authentication, Client still names the provider
+	Unsigned bool
+	Parent   http.RoundTripper
+	// Retry is off when nil
+	Retry      *RetryPolicy
+	Log        *LogOptions
+	Instrument Instrument
+	Propagator Propagator
+}
+
+type tokenRefresher interface {
+	Refresh(ctx context.Context, oldToken *Token) (token *Token, err error)
 }
 
 func (t *Transport) RoundTrip(req *http.Request) (res *http.Response, err error) {
-	if t.Client != nil {
-		err = t.Client.Signature(req, t.Token, nil)
+	if t.Retry == nil {
+		return t.send(req)
+	}
+	return t.Retry.do(req, t.send)
+}
+
+func (t *Transport) send(req *http.Request) (res *http.Response, err error) {
+	if t.Source == nil {
+		return t.roundTrip(req, t.Token)
+	}
+
+	var token *Token
+	if token, err = t.Source.Token(req.Context()); err != nil {
+		return
+	}
+
+	refresher, ok := t.Source.(tokenRefresher)
+	if !ok || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return t.roundTrip(req, token)
+	}
+
+	// signing modifies the request, keep a copy to retry
+	retry := req.Clone(req.Context())
+	if res, err = t.roundTrip(req, token); err != nil || res.StatusCode != http.StatusUnauthorized {
+		return
+	}
+
+	newToken, e := refresher.Refresh(retry.Context(), token)
+	if e != nil {
+		return
+	}
+	if retry.GetBody != nil {
+		if retry.Body, e = retry.GetBody(); e != nil {
+			return
+		}
+	}
+	res.Body.Close()
+	res, err = t.roundTrip(retry, newToken)
+	return
+}
+
+func (t *Transport) roundTrip(req *http.Request, token *Token) (res *http.Response, err error) {
+	if t.Client != nil && !t.Unsigned {
+		err = t.Client.Signature(req, token, nil)
 		if err != nil {
 			return
 		}
@@ -25,28 +80,53 @@ func (t *Transport) RoundTrip(req *http.Request) (res *http.Response, err error)
 	} else {
 		transport = http.DefaultTransport
 	}
-	if DEBUG {
-		var isBody bool
-		if req.Header.Get("Content-Type") == "application/x-www-form-urlencoded" || req.Header.Get("Content-Type") == "application/json" {
-			isBody = true
-		}
-		dump, _ := httputil.DumpRequest(req, isBody)
-		fmt.Println("")
-		fmt.Println("")
-		fmt.Println(string(dump))
-		fmt.Println("")
-		fmt.Println("")
+	countAttempt(req.Context())
+
+	ctx := req.Context()
+	if t.Propagator != nil {
+		t.Propagator.Inject(ctx, req.Header)
+	}
+	logOptions := t.logOptions()
+	if logOptions != nil && !logOptions.enabled(ctx) {
+		logOptions = nil
+	}
+	var provider string
+	if t.Client != nil {
+		provider = t.Client.Name()
+	}
+	if logOptions != nil {
+		logOptions.logRequest(ctx, provider, req)
 	}
 
+	start := time.Now()
 	res, err = transport.RoundTrip(req)
+	duration := time.Since(start)
 
-	if DEBUG {
-		dump, _ := httputil.DumpResponse(res, true)
-		fmt.Println("")
-		fmt.Println("")
-		fmt.Println(string(dump))
-		fmt.Println("")
-		fmt.Println("")
+	if logOptions != nil {
+		logOptions.logResponse(ctx, provider, req, res, err, duration)
+	}
+	if t.Instrument != nil {
+		if err != nil {
+			t.observe(req, res, err, duration)
+		} else {
+			res.Body = &observedBody{
+				ReadCloser: res.Body,
+				observe: func() {
+					t.observe(req, res, nil, duration)
+				},
+			}
+		}
 	}
 	return
 }
+
+// logOptions falls back to a debug logger on stdout when DEBUG is set
+func (t *Transport) logOptions() *LogOptions {
+	if t.Log != nil && t.Log.Logger != nil {
+		return t.Log
+	}
+	if DEBUG {
+		return debugLog
+	}
+	return nil
+}
@@ -12,6 +12,16 @@ type (
 	Client struct {
 		oauth.OAuth2
 	}
+
+	userResponse struct {
+		Data []struct {
+			ID              oauth.ID `json:"id"`
+			Login           string   `json:"login"`
+			DisplayName     string   `json:"display_name"`
+			ProfileImageURL string   `json:"profile_image_url"`
+			Email           string   `json:"email"`
+		} `json:"data"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -21,11 +31,28 @@ var Endpoint = oauth.Endpoint{
 	RefreshTokenURL: "https://id.twitch.tv/oauth2/token",
 	RevokeTokenURL:  "https://id.twitch.tv/oauth2/revoke",
 	APIURL:          "https://api.twitch.tv/helix",
+	DeviceURL:       "https://id.twitch.tv/oauth2/device",
 	ClientHeader:    "Bearer",
 	TokenHeader:     "Bearer",
 }
 
+func init() {
+	oauth.Register("twitch", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
+}
+
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
 	var req *http.Request
 	if req, err = http.NewRequest("GET", c.Endpoint.APIURL+"/users", nil); err != nil {
@@ -33,43 +60,32 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 	}
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
 		return
 	}
-	var ok bool
-	var data []interface{}
-	if data, ok = raw["data"].([]interface{}); !ok || data == nil || len(data) == 0 {
-		err = oauth.NewError("raw.data is empty", 500)
+	if len(res.Data) == 0 || res.Data[0].ID == "" {
+		err = oauth.UnexpectedResponse(raw, "data[0].id is missing or invalid")
 		return
 	}
-	if raw, ok = data[0].(map[string]interface{}); !ok || raw == nil || len(raw) == 0 {
-		err = oauth.NewError("raw.data is empty", 500)
-		return
+	if data, ok := raw["data"].([]interface{}); ok && len(data) != 0 {
+		raw, _ = data[0].(map[string]interface{})
 	}
+	u := res.Data[0]
 	user = &oauth.User{
-		ID:      raw["id"].(string),
-		Raw:     raw,
-		Updated: &now,
-	}
-
-	if v, ok := raw["login"].(string); ok {
-		user.Username = v
-	}
-	if v, ok := raw["display_name"].(string); ok {
-		user.Nickname = v
-	}
-	if v, ok := raw["profile_image_url"].(string); ok {
-		user.Avatar = v
+		ID:       u.ID.String(),
+		Username: u.Login,
+		Nickname: u.DisplayName,
+		Avatar:   u.ProfileImageURL,
+		Raw:      raw,
+		Updated:  &now,
 	}
 
-	if v, ok := raw["email"].(string); ok && v != "" {
-		if user.Auths == nil {
-			user.Auths = make([]*oauth.Auth, 0)
-		}
+	if u.Email != "" {
 		user.Auths = append(user.Auths, &oauth.Auth{
 			Type:     "email",
-			Value:    v,
+			Value:    u.Email,
 			Verified: true,
 		})
 	}
@@ -0,0 +1,24 @@
+package twitch
+
+import (
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token"}, []providertest.Case{
+		{Name: "missing data", Body: `{}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "empty data", Body: `{"data":[]}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "missing id", Body: `{"data":[{"login":"a"}]}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "string id", Body: `{"data":[{"id":"1234567890123456789"}]}`, ID: "1234567890123456789"},
+		{Name: "number id", Body: `{"data":[{"id":1234567890123456789}]}`, ID: "1234567890123456789"},
+		{Name: "float id", Body: `{"data":[{"id":1.5}]}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed data", Body: `{"data":{"id":"1"}}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"data":[{"id":"1","login":5,"display_name":[],"email":{}}]}`, ID: "1"},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "error with status 200", Body: `{"error":"invalid_token","message":"bad token"}`, Err: oauth.ErrTokenInvalid},
+	})
+}
@@ -13,6 +13,18 @@ type (
 	Client struct {
 		oauth.OAuth1
 	}
+
+	userResponse struct {
+		ID                   oauth.ID `json:"id"`
+		IDStr                oauth.ID `json:"id_str"`
+		ScreenName           string   `json:"screen_name"`
+		Name                 string   `json:"name"`
+		Description          string   `json:"description"`
+		ProfileImageURLHTTPS string   `json:"profile_image_url_https"`
+		Lang                 string   `json:"lang"`
+		Email                string   `json:"email"`
+		CreatedAt            string   `json:"created_at"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -21,9 +33,32 @@ var Endpoint = oauth.Endpoint{
 	AuthorizeURL:   "https://api.twitter.com/oauth/authorize",
 	AccessTokenURL: "https://api.twitter.com/oauth/access_token",
 	APIURL:         "https://api.twitter.com/1.1",
+	// https://developer.twitter.com/en/support/twitter-api/error-troubleshooting
+	ErrorCodes: map[string]string{
+		"32":  "token_invalid",
+		"88":  "rate_limited",
+		"89":  "token_invalid",
+		"326": "consent_revoked",
+	},
+}
+
+func init() {
+	oauth.Register("twitter", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth1: oauth.OAuth1{
+			Config: config,
+		},
+	}
 }
 
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
 	var req *http.Request
 	if req, err = http.NewRequest("GET", c.Endpoint.APIURL+"/account/verify_credentials.json?include_email=true", nil); err != nil {
@@ -31,46 +66,43 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 	}
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
+		return
+	}
+	id := res.IDStr.String()
+	if id == "" {
+		id = res.ID.String()
+	}
+	if id == "" {
+		err = oauth.UnexpectedResponse(raw, "id_str is missing or invalid")
 		return
 	}
 
 	user = &oauth.User{
-		ID:      raw["id_str"].(string),
-		Raw:     raw,
-		Updated: &now,
+		ID:          id,
+		Username:    res.ScreenName,
+		Name:        res.Name,
+		Description: res.Description,
+		Avatar:      strings.Replace(res.ProfileImageURLHTTPS, "_normal.jpeg", ".jpeg", 1),
+		Locale:      oauth.FormatLocale(res.Lang),
+		Raw:         raw,
+		Updated:     &now,
 	}
 
-	if v, ok := raw["created_at"].(string); ok && v != "" {
-		if created, err := time.Parse(time.RubyDate, v); err == nil {
+	if res.CreatedAt != "" {
+		if created, err := time.Parse(time.RubyDate, res.CreatedAt); err == nil {
 			user.Created = &created
 		}
 	}
-
-	if v, ok := raw["screen_name"].(string); ok && v != "" {
-		user.Username = v
-		user.Link = "https://twitter.com/" + v
-	}
-	if v, ok := raw["name"].(string); ok {
-		user.Name = v
+	if res.ScreenName != "" {
+		user.Link = "https://twitter.com/" + res.ScreenName
 	}
-	if v, ok := raw["description"].(string); ok {
-		user.Description = v
-	}
-	if v, ok := raw["profile_image_url_https"].(string); ok {
-		user.Avatar = strings.Replace(v, "_normal.jpeg", ".jpeg", 1)
-	}
-	if v, ok := raw["lang"].(string); ok {
-		user.Locale = oauth.FormatLocale(v)
-	}
-	if v, ok := raw["email"].(string); ok && v != "" {
-		if user.Auths == nil {
-			user.Auths = make([]*oauth.Auth, 0)
-		}
+	if res.Email != "" {
 		user.Auths = append(user.Auths, &oauth.Auth{
 			Type:     "email",
-			Value:    v,
+			Value:    res.Email,
 			Verified: true,
 		})
 	}
@@ -0,0 +1,42 @@
+package twitter
+
+import (
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	locale := func(want string) func(t *testing.T, user *oauth.User) {
+		return func(t *testing.T, user *oauth.User) {
+			if user.Locale != want {
+				t.Fatalf("User().Locale = %q, want %q", user.Locale, want)
+			}
+		}
+	}
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token", TokenSecret: "token_secret"}, []providertest.Case{
+		{Name: "missing id_str and id", Body: `{"screen_name":"jack"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "string id_str", Body: `{"id":1234567890123456800,"id_str":"1234567890123456789","screen_name":"jack","lang":"en","created_at":"Tue Mar 21 20:50:14 +0000 2006","email":"jack@example.com"}`, ID: "1234567890123456789", Check: func(t *testing.T, user *oauth.User) {
+			if user.Link != "https://twitter.com/jack" || user.Created == nil || user.Locale != "en" || len(user.Auths) != 1 {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "number id_str", Body: `{"id_str":1234567890123456789}`, ID: "1234567890123456789"},
+		{Name: "number id without id_str", Body: `{"id":1234567890123456789}`, ID: "1234567890123456789"},
+		{Name: "float id without id_str", Body: `{"id":1.5}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "float id_str and id", Body: `{"id":1.5,"id_str":2.5}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"id_str":"1","screen_name":5,"name":[],"profile_image_url_https":{},"lang":7,"email":true,"created_at":1142974214}`, ID: "1", Check: func(t *testing.T, user *oauth.User) {
+			if user.Username != "" || user.Link != "" || user.Locale != "" || user.Created != nil || len(user.Auths) != 0 {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "lang with empty segments", Body: `{"id_str":"1","lang":"-a-b-c"}`, ID: "1", Check: locale("a-B-C")},
+		{Name: "lang with underscores", Body: `{"id_str":"1","lang":"_a_b"}`, ID: "1", Check: locale("a-B")},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "invalid token code", Status: 401, Body: `{"errors":[{"code":89,"message":"Invalid or expired token."}]}`, Err: oauth.ErrTokenInvalid},
+		{Name: "rate limit code", Status: 429, Body: `{"errors":[{"code":88,"message":"Rate limit exceeded"}]}`, Err: oauth.ErrRateLimited},
+		{Name: "error with status 200", Body: `{"error":"invalid_token","error_description":"Invalid or expired token."}`, Err: oauth.ErrTokenInvalid},
+	})
+}
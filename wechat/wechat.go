@@ -14,6 +14,15 @@ type (
 	Client struct {
 		oauth.OAuth2
 	}
+
+	userResponse struct {
+		OpenID     string `json:"openid"`
+		UnionID    string `json:"unionid"`
+		Nickname   string `json:"nickname"`
+		HeadImgURL string `json:"headimgurl"`
+		Country    string `json:"country"`
+		Sex        int    `json:"sex"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -25,6 +34,35 @@ var Endpoint = oauth.Endpoint{
 	Errors:          []string{"errmsg", "errcode"},
 	ClientIDKey:     "appid",
 	ClientSecretKey: "secret",
+	// https://developers.weixin.qq.com/doc/oplatform/Return_codes/Return_code_descriptions_new.html
+	ErrorCodes: map[string]string{
+		"40001": "token_invalid",
+		"40014": "token_invalid",
+		"42001": "token_expired",
+		"42002": "invalid_grant",
+		"42003": "invalid_grant",
+		"40029": "invalid_grant",
+		"40030": "invalid_grant",
+		"40163": "invalid_grant",
+		"42007": "consent_revoked",
+		"45009": "rate_limited",
+		"45011": "rate_limited",
+	},
+}
+
+func init() {
+	oauth.Register("wechat", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
 }
 
 func (c *Client) Authorize(ctx context.Context, state string, values url.Values) (authorizeURL *url.URL, data map[string]interface{}, err error) {
@@ -58,6 +96,7 @@ func (c *Client) Signature(req *http.Request, token *oauth.Token, values url.Val
 }
 
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
 	var req *http.Request
 	if req, err = http.NewRequest("GET", c.Endpoint.APIURL+"/sns/userinfo?lang=en", nil); err != nil {
@@ -65,46 +104,41 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 	}
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
 		return
 	}
-	id := token.OpenID
-	if v, ok := raw["openid"].(string); ok && v != "" {
-		id = v
+	if res.OpenID == "" {
+		err = oauth.UnexpectedResponse(raw, "openid is missing or invalid")
+		return
 	}
-	if v, ok := raw["unionid"].(string); ok && v != "" {
-		id = v
+	id := res.OpenID
+	if res.UnionID != "" {
+		id = res.UnionID
 	}
 
 	user = &oauth.User{
-		ID:      id,
-		Raw:     raw,
-		Updated: &now,
-	}
-	if v, ok := raw["nickname"].(string); ok {
-		user.Nickname = v
+		ID:       id,
+		Nickname: res.Nickname,
+		Locale:   oauth.FormatLocale(res.Country),
+		Raw:      raw,
+		Updated:  &now,
 	}
 
-	if v, ok := raw["headimgurl"].(string); ok && v != "" {
-		avatar := strings.Split(v, "/")
+	if res.HeadImgURL != "" {
+		avatar := strings.Split(res.HeadImgURL, "/")
 		if len(avatar[len(avatar)-1]) > 1 && len(avatar[len(avatar)-1]) < 4 {
 			avatar[len(avatar)-1] = "0"
 		}
 		user.Avatar = strings.Join(avatar, "/")
 	}
 
-	if v, ok := raw["country"].(string); ok {
-		user.Locale = oauth.FormatLocale(v)
-	}
-
-	if v, ok := raw["gender"].(string); ok {
-		switch v {
-		case "1":
-			user.Gender = "male"
-		case "2":
-			user.Gender = "female"
-		}
+	switch res.Sex {
+	case 1:
+		user.Gender = "male"
+	case 2:
+		user.Gender = "female"
 	}
 	return
 }
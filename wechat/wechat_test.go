@@ -0,0 +1,37 @@
+package wechat
+
+import (
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	providertest.Run(t, client, &oauth.Token{AccessToken: "token", OpenID: "token_openid"}, []providertest.Case{
+		{Name: "missing openid", Body: `{"nickname":"a"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "string openid", Body: `{"openid":"openid"}`, ID: "openid"},
+		{Name: "unionid", Body: `{"openid":"openid","unionid":"unionid"}`, ID: "unionid"},
+		{Name: "number openid", Body: `{"openid":1234567890123456789}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "float openid", Body: `{"openid":1.5}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"openid":"openid","unionid":5,"nickname":[],"headimgurl":{},"country":1,"sex":"1"}`, ID: "openid", Check: func(t *testing.T, user *oauth.User) {
+			if user.Nickname != "" || user.Avatar != "" || user.Locale != "" || user.Gender != "" {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "short headimgurl", Body: `{"openid":"openid","headimgurl":"/","sex":2}`, ID: "openid", Check: func(t *testing.T, user *oauth.User) {
+			if user.Gender != "female" {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "country with empty segments", Body: `{"openid":"openid","country":"-us-x"}`, ID: "openid", Check: func(t *testing.T, user *oauth.User) {
+			if user.Locale != "us-X" {
+				t.Fatalf("User().Locale = %q", user.Locale)
+			}
+		}},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "errcode with status 200", Body: `{"errcode":40001,"errmsg":"invalid credential"}`, Err: oauth.ErrTokenInvalid},
+		{Name: "expired errcode with status 200", Body: `{"errcode":42001,"errmsg":"access_token expired"}`, Err: oauth.ErrTokenExpired},
+	})
+}
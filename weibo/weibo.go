@@ -13,6 +13,20 @@ type (
 	Client struct {
 		oauth.OAuth2
 	}
+
+	userResponse struct {
+		ID              oauth.ID `json:"id"`
+		Name            string   `json:"name"`
+		Domain          string   `json:"domain"`
+		Description     string   `json:"description"`
+		ProfileURL      string   `json:"profile_url"`
+		Gender          string   `json:"gender"`
+		Lang            string   `json:"lang"`
+		ProfileImageURL string   `json:"profile_image_url"`
+		AvatarLarge     string   `json:"avatar_large"`
+		AvatarHD        string   `json:"avatar_hd"`
+		CreatedAt       string   `json:"created_at"`
+	}
 )
 
 var Endpoint = oauth.Endpoint{
@@ -24,6 +38,35 @@ var Endpoint = oauth.Endpoint{
 	APIURL:          "https://api.weibo.com/2",
 	ScopeSep:        ",",
 	ClientHeader:    "Basic",
+	Errors:          []string{"error_code"},
+	// https://open.weibo.com/wiki/Error_code
+	ErrorCodes: map[string]string{
+		"10022": "rate_limited",
+		"10023": "rate_limited",
+		"10024": "rate_limited",
+		"21315": "token_expired",
+		"21316": "consent_revoked",
+		"21317": "token_invalid",
+		"21319": "consent_revoked",
+		"21325": "invalid_grant",
+		"21327": "token_expired",
+		"21332": "token_invalid",
+	},
+}
+
+func init() {
+	oauth.Register("weibo", func(config oauth.Config) oauth.Client {
+		return New(config)
+	})
+}
+
+func New(config oauth.Config) *Client {
+	config.Endpoint = oauth.MergeEndpoint(Endpoint, config.Endpoint)
+	return &Client{
+		OAuth2: oauth.OAuth2{
+			Config: config,
+		},
+	}
 }
 
 func (c *Client) RevokeToken(ctx context.Context, token *oauth.Token, values url.Values) (err error) {
@@ -38,9 +81,10 @@ func (c *Client) RevokeToken(ctx context.Context, token *oauth.Token, values url
 }
 
 func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User, err error) {
+	ctx = oauth.WithOperation(ctx, oauth.OperationUser)
 	now := time.Now()
-	uid, ok := token.Raw["uid"].(string)
-	if !ok || uid == "" {
+	uid := oauth.RawID(token.Raw["uid"])
+	if uid == "" {
 		err = oauth.NewError("Token.Raw.uid is required", 500)
 		return
 	}
@@ -50,55 +94,45 @@ func (c *Client) User(ctx context.Context, token *oauth.Token) (user *oauth.User
 	}
 	httpClient := oauth.HTTPClient(ctx, c, token)
 
+	var res userResponse
 	var raw map[string]interface{}
-	if raw, err = c.Response(ctx, httpClient, req); err != nil {
+	if raw, err = c.ResponseInto(ctx, httpClient, req, &res); err != nil {
+		return
+	}
+	if res.ID == "" {
+		err = oauth.UnexpectedResponse(raw, "id is missing or invalid")
 		return
 	}
 
 	user = &oauth.User{
-		ID:      uid,
-		Raw:     raw,
-		Updated: &now,
+		ID:          uid,
+		Username:    res.Domain,
+		Nickname:    res.Name,
+		Description: res.Description,
+		Locale:      oauth.FormatLocale(res.Lang),
+		Raw:         raw,
+		Updated:     &now,
 	}
 
-	if v, ok := raw["created_at"].(string); ok && v != "" {
-		if created, err := time.Parse(time.RubyDate, v); err == nil {
+	if res.CreatedAt != "" {
+		if created, err := time.Parse(time.RubyDate, res.CreatedAt); err == nil {
 			user.Created = &created
 		}
 	}
-	if v, ok := raw["profile_url"].(string); ok && v != "" {
-		user.Link = "https://weibo.com/" + v
+	if res.ProfileURL != "" {
+		user.Link = "https://weibo.com/" + res.ProfileURL
 	}
-
-	if v, ok := raw["domain"].(string); ok && v != "" {
-		user.Username = v
-	}
-	if v, ok := raw["name"].(string); ok {
-		user.Nickname = v
-	}
-	if v, ok := raw["description"].(string); ok {
-		user.Description = v
-	}
-	if v, ok := raw["gender"].(string); ok {
-		switch v {
-		case "m":
-			user.Gender = "male"
-		case "f":
-			user.Gender = "female"
-		}
+	switch res.Gender {
+	case "m":
+		user.Gender = "male"
+	case "f":
+		user.Gender = "female"
 	}
 
-	if v, ok := raw["lang"].(string); ok {
-		user.Locale = oauth.FormatLocale(v)
-	}
-	if v, ok := raw["profile_image_url"].(string); ok && v != "" {
-		user.Avatar = v
-	}
-	if v, ok := raw["avatar_large"].(string); ok && v != "" {
-		user.Avatar = v
-	}
-	if v, ok := raw["avatar_hd"].(string); ok && v != "" {
-		user.Avatar = v
+	for _, avatar := range []string{res.ProfileImageURL, res.AvatarLarge, res.AvatarHD} {
+		if avatar != "" {
+			user.Avatar = avatar
+		}
 	}
 
 	return
@@ -0,0 +1,33 @@
+package weibo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/otamoe/oauth-client"
+	"github.com/otamoe/oauth-client/internal/providertest"
+)
+
+func TestUser(t *testing.T) {
+	client := New(oauth.Config{ClientID: "client", ClientSecret: "secret"})
+	token := &oauth.Token{AccessToken: "token", Raw: map[string]interface{}{"uid": json.Number("1234567890123456789")}}
+	providertest.Run(t, client, token, []providertest.Case{
+		{Name: "missing id", Body: `{"name":"a"}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "string id", Body: `{"id":"1234567890123456789"}`, ID: "1234567890123456789"},
+		{Name: "number id", Body: `{"id":1234567890123456789}`, ID: "1234567890123456789"},
+		{Name: "float id", Body: `{"id":1.5}`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "wrong typed fields", Body: `{"id":1,"name":5,"domain":[],"gender":{},"lang":1,"profile_url":true,"avatar_hd":[],"created_at":1}`, ID: "1234567890123456789", Check: func(t *testing.T, user *oauth.User) {
+			if user.Nickname != "" || user.Username != "" || user.Gender != "" || user.Link != "" || user.Avatar != "" || user.Created != nil {
+				t.Fatalf("User() = %+v", user)
+			}
+		}},
+		{Name: "lang with empty segments", Body: `{"id":1,"lang":"-a-b-c"}`, ID: "1234567890123456789", Check: func(t *testing.T, user *oauth.User) {
+			if user.Locale != "a-B-C" {
+				t.Fatalf("User().Locale = %q", user.Locale)
+			}
+		}},
+		{Name: "non-JSON body", ContentType: "text/html", Body: `<html>oops</html>`, Err: oauth.ErrUnexpectedResponse},
+		{Name: "error_code with status 200", Body: `{"error":"expired_token","error_code":21327,"request":"/2/users/show.json"}`, Err: oauth.ErrTokenExpired},
+		{Name: "invalid error_code with status 200", Body: `{"error":"invalid_access_token","error_code":21332}`, Err: oauth.ErrTokenInvalid},
+	})
+}